@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/RichestHumanAlive/ppm_cli/pkg/manager"
+)
+
+// packageColumn is a column of a package table that --columns can pick
+type packageColumn struct {
+	column
+	name  string // The --columns name, set by selectPackageColumns
+	value func(pkg manager.Package) string
+}
+
+// packageColumns are the columns of package tables by --columns name
+var packageColumns = map[string]packageColumn{
+	"name": {
+		column: column{header: "Name", style: titleStyle},
+		value:  func(pkg manager.Package) string { return pkg.Name },
+	},
+	"version": {
+		column: column{header: "Version", style: versionStyle, priority: 1},
+		value:  func(pkg manager.Package) string { return pkg.Version },
+	},
+	"provider": {
+		column: column{header: "Provider", style: providerStyle},
+		value:  func(pkg manager.Package) string { return pkg.Provider },
+	},
+	"description": {
+		column: column{header: "Description", style: descStyle, overflow: overflowTruncate, priority: 3},
+		value:  func(pkg manager.Package) string { return firstLine(pkg.Description) },
+	},
+	"author": {
+		column: column{header: "Author", style: descStyle, overflow: overflowTruncate, priority: 2},
+		value:  func(pkg manager.Package) string { return pkg.Author },
+	},
+	"license": {
+		column: column{header: "License", style: descStyle, priority: 2},
+		value:  func(pkg manager.Package) string { return pkg.License },
+	},
+	"downloads": {
+		column: column{header: "Downloads", style: versionStyle, priority: 2},
+		value: func(pkg manager.Package) string {
+			if pkg.Downloads == 0 {
+				return ""
+			}
+			return strconv.FormatInt(pkg.Downloads, 10)
+		},
+	},
+	"score": {
+		column: column{header: "Score", style: versionStyle, priority: 2},
+		value:  func(pkg manager.Package) string { return strconv.FormatFloat(pkg.Score, 'f', 2, 64) },
+	},
+	"homepage": {
+		column: column{header: "Homepage", style: descStyle, overflow: overflowTruncate, priority: 2},
+		value:  func(pkg manager.Package) string { return pkg.Homepage },
+	},
+	"repository": {
+		column: column{header: "Repository", style: descStyle, overflow: overflowTruncate, priority: 2},
+		value:  func(pkg manager.Package) string { return pkg.Repository },
+	},
+	"source": {
+		column: column{header: "Source", style: descStyle, overflow: overflowTruncate, priority: 2},
+		value:  func(pkg manager.Package) string { return pkg.Source },
+	},
+	"scope": {
+		column: column{header: "Scope", style: descStyle, priority: 2},
+		value:  func(pkg manager.Package) string { return pkg.Scope },
+	},
+	"updated": {
+		column: column{header: "Updated", style: versionStyle, priority: 2},
+		value:  func(pkg manager.Package) string { return pkg.Updated },
+	},
+}
+
+// packageColumnNames lists the --columns names in the order of manager.Package
+var packageColumnNames = []string{
+	"name", "version", "provider", "description", "author", "license",
+	"downloads", "score", "homepage", "repository", "source", "scope", "updated",
+}
+
+// selectPackageColumns looks up the package table columns named by --columns
+func selectPackageColumns(names []string) ([]packageColumn, error) {
+	cols := make([]packageColumn, 0, len(names))
+	for _, name := range names {
+		key := strings.ToLower(strings.TrimSpace(name))
+		c, ok := packageColumns[key]
+		if !ok {
+			return nil, UsageError(fmt.Errorf("unknown column %q (expected %s)", name, strings.Join(packageColumnNames, ", ")))
+		}
+		c.name = key
+		cols = append(cols, c)
+	}
+	if len(cols) == 0 {
+		return nil, UsageError(fmt.Errorf("--columns needs at least one column"))
+	}
+	return cols, nil
+}
+
+// renderPackageTable renders packages with the given columns
+func renderPackageTable(pkgs []manager.Package, cols []packageColumn) string {
+	return renderTable(packageTable(pkgs, cols))
+}
+
+// packageTable returns the columns and rows of a package table
+func packageTable(pkgs []manager.Package, cols []packageColumn) ([]column, [][]string) {
+	columns := make([]column, 0, len(cols))
+	for _, c := range cols {
+		columns = append(columns, c.column)
+	}
+
+	rows := make([][]string, 0, len(pkgs))
+	for _, pkg := range pkgs {
+		row := make([]string, 0, len(cols))
+		for _, c := range cols {
+			row = append(row, c.value(pkg))
+		}
+		rows = append(rows, row)
+	}
+	return columns, rows
+}
+
+// columnsUsage is the help of a --columns flag
+func columnsUsage() string {
+	return "table columns to show: " + strings.Join(packageColumnNames, ", ")
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	errTimedOut    = errors.New("operation timed out (see --timeout)")
+	errInterrupted = errors.New("operation interrupted")
+)
+
+// interruptKey is the context key of the cancel func interrupt calls
+type interruptKey struct{}
+
+// timeoutKey is the context key of the global --timeout flag
+type timeoutKey struct{}
+
+// operationContext derives the context for a command's package manager
+// operations from the command context. It is cancelled by interrupt and
+// carries the global --timeout flag for withOperationTimeout.
+func operationContext(cmd *cobra.Command) (context.Context, context.CancelFunc) {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	if timeout, err := cmd.Flags().GetDuration("timeout"); err == nil && timeout > 0 {
+		ctx = context.WithValue(ctx, timeoutKey{}, timeout)
+	}
+	return context.WithValue(ctx, interruptKey{}, cancel), cancel
+}
+
+// withOperationTimeout derives the context of a single package manager
+// operation, which is given the --timeout carried by ctx in full. A zero
+// timeout leaves the operation unbounded.
+func withOperationTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if timeout, ok := ctx.Value(timeoutKey{}).(time.Duration); ok {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// interrupt cancels the command operation ctx belongs to, as SIGINT would.
+// Spinners and progress displays put the terminal in raw mode, where Ctrl-C
+// arrives as a key press instead of a signal, and call it so that the whole
+// command stops rather than only the step they show.
+func interrupt(ctx context.Context) {
+	if cancel, ok := ctx.Value(interruptKey{}).(context.CancelFunc); ok {
+		cancel()
+	}
+}
+
+// interruptedError replaces err with a clearer message when the operation
+// stopped because ctx was cancelled or timed out
+func interruptedError(ctx context.Context, err error) error {
+	switch ctx.Err() {
+	case context.DeadlineExceeded:
+		return errTimedOut
+	case context.Canceled:
+		return errInterrupted
+	}
+	return err
+}
+
+// isInterrupted reports whether err means the operation was interrupted or
+// timed out, which commands return as is so that the exit code tells
+func isInterrupted(err error) bool {
+	return errors.Is(err, errInterrupted) || errors.Is(err, errTimedOut)
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// timedCommandContext returns the context of a command operation run with
+// --timeout in a non-interactive session
+func timedCommandContext(t *testing.T, timeout time.Duration) context.Context {
+	t.Helper()
+	saved := noInput
+	noInput = true
+	t.Cleanup(func() { noInput = saved })
+
+	cmd := &cobra.Command{}
+	cmd.Flags().Duration("timeout", timeout, "")
+	cmd.SetContext(context.Background())
+	ctx, cancel := operationContext(cmd)
+	t.Cleanup(cancel)
+	return ctx
+}
+
+// sleep waits for d unless ctx is done first
+func sleep(d time.Duration) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		select {
+		case <-time.After(d):
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func TestTimeoutAppliesPerTask(t *testing.T) {
+	ctx := timedCommandContext(t, 200*time.Millisecond)
+	pm := &stubManager{name: "npm", change: sleep(100 * time.Millisecond)}
+
+	// One at a time, the tasks take longer together than the timeout
+	tasks := make([]task, 3)
+	for i := range tasks {
+		name := fmt.Sprintf("pkg%d", i)
+		tasks[i] = task{label: name, pm: pm, run: func(ctx context.Context) error { return pm.Remove(ctx, name) }}
+	}
+	for i, err := range runTasks(ctx, tasks, 1) {
+		if err != nil {
+			t.Errorf("task %d failed: %v", i, err)
+		}
+	}
+}
+
+func TestTimeoutExpires(t *testing.T) {
+	ctx := timedCommandContext(t, 50*time.Millisecond)
+
+	err := runWithSpinner(ctx, "Installing...", sleep(time.Minute))
+	if !errors.Is(err, errTimedOut) {
+		t.Errorf("runWithSpinner() = %v, want %v", err, errTimedOut)
+	}
+	if ctx.Err() != nil {
+		t.Errorf("the command context is done (%v), want only the operation timed out", ctx.Err())
+	}
+
+	// The next operation is given the timeout again
+	if err := runWithSpinner(ctx, "Installing...", sleep(10*time.Millisecond)); err != nil {
+		t.Errorf("runWithSpinner() after a timeout = %v, want nil", err)
+	}
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"errors"
+
+	"github.com/spf13/cobra"
+)
+
+// Exit codes, one per failure category, so that scripts and CI jobs can tell
+// failures apart
+const (
+	ExitOK            = 0
+	ExitFailure       = 1   // An operation failed, or a check found problems (outdated packages, lock drift)
+	ExitUsage         = 2   // Invalid command line
+	ExitInputRequired = 3   // A prompt was needed but PPM cannot ask (see --yes and --no-input)
+	ExitTimedOut      = 124 // --timeout expired, like timeout(1)
+	ExitInterrupted   = 130 // Ctrl-C or SIGTERM, like a shell reports SIGINT
+)
+
+// exitError tags an error with the exit code of its category
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+// UsageError marks err as a mistake in the command line
+func UsageError(err error) error {
+	return &exitError{code: ExitUsage, err: err}
+}
+
+// inputRequiredError marks err as a prompt PPM could not show
+func inputRequiredError(err error) error {
+	return &exitError{code: ExitInputRequired, err: err}
+}
+
+// ExitCode returns the exit code for an error returned by a command
+func ExitCode(err error) int {
+	var exitErr *exitError
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.Is(err, errInterrupted):
+		return ExitInterrupted
+	case errors.Is(err, errTimedOut):
+		return ExitTimedOut
+	case errors.As(err, &exitErr):
+		return exitErr.code
+	default:
+		return ExitFailure
+	}
+}
+
+// MarkUsageErrors makes flag and argument errors of root and its subcommands
+// report ExitUsage
+func MarkUsageErrors(root *cobra.Command) {
+	root.SetFlagErrorFunc(func(c *cobra.Command, err error) error {
+		return UsageError(err)
+	})
+
+	var walk func(c *cobra.Command)
+	walk = func(c *cobra.Command) {
+		if args := c.Args; args != nil {
+			c.Args = func(c *cobra.Command, a []string) error {
+				if err := args(c, a); err != nil {
+					return UsageError(err)
+				}
+				return nil
+			}
+		}
+		for _, sub := range c.Commands() {
+			walk(sub)
+		}
+	}
+	walk(root)
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"success", nil, ExitOK},
+		{"failure", errors.New("npm exited with status 1"), ExitFailure},
+		{"usage", UsageError(errors.New("unknown column")), ExitUsage},
+		{"input required", inputRequiredError(errors.New("ambiguous package")), ExitInputRequired},
+		{"timed out", errTimedOut, ExitTimedOut},
+		{"interrupted", errInterrupted, ExitInterrupted},
+		{"wrapped usage", fmt.Errorf("list: %w", UsageError(errors.New("unknown sort key"))), ExitUsage},
+		{"wrapped interrupted", fmt.Errorf("install: %w", errInterrupted), ExitInterrupted},
+	}
+
+	for _, tt := range tests {
+		if got := ExitCode(tt.err); got != tt.want {
+			t.Errorf("%s: ExitCode(%v) = %d, want %d", tt.name, tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestMarkUsageErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{"unknown flag", []string{"install", "--frobnicate", "lodash"}, ExitUsage},
+		{"invalid flag value", []string{"install", "--jobs", "many", "lodash"}, ExitUsage},
+		{"missing argument", []string{"install"}, ExitUsage},
+		{"unknown command", []string{"instal", "lodash"}, ExitUsage},
+		{"command failure", []string{"install", "lodash"}, ExitFailure},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := &cobra.Command{Use: "ppm", Args: cobra.NoArgs, RunE: func(c *cobra.Command, args []string) error { return nil }}
+			install := &cobra.Command{
+				Use:  "install <package>...",
+				Args: cobra.MinimumNArgs(1),
+				RunE: func(c *cobra.Command, args []string) error { return errors.New("npm exited with status 1") },
+			}
+			install.Flags().Int("jobs", 1, "")
+			root.AddCommand(install)
+			root.SetArgs(tt.args)
+			root.SetOut(io.Discard)
+			root.SetErr(io.Discard)
+
+			MarkUsageErrors(root)
+			if got := ExitCode(root.Execute()); got != tt.want {
+				t.Errorf("exit code = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfirmChanges(t *testing.T) {
+	savedYes, savedNoInput := assumeYes, noInput
+	t.Cleanup(func() { assumeYes, noInput = savedYes, savedNoInput })
+
+	assumeYes, noInput = true, true
+	if err := confirmChanges(3); err != nil {
+		t.Errorf("confirmChanges() with --yes = %v, want nil", err)
+	}
+
+	assumeYes = false
+	if err := confirmChanges(3); ExitCode(err) != ExitInputRequired {
+		t.Errorf("confirmChanges() without --yes = %v, want an input required error", err)
+	}
+}
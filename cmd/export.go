@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/RichestHumanAlive/ppm_cli/pkg/envfile"
+	"github.com/RichestHumanAlive/ppm_cli/pkg/manager"
+	"github.com/spf13/cobra"
+)
+
+// captureEnvironment snapshots the installed packages and versions of every
+// available package manager in pms. Package managers that fail are reported
+// in the returned map and left out of the snapshot.
+func captureEnvironment(ctx context.Context, pms []manager.PackageManager) (*envfile.File, map[string]error) {
+	var mu sync.Mutex
+	env := envfile.New(runtime.GOOS, runtime.GOARCH)
+
+	errs := forEachAvailable(ctx, pms, func(pm manager.PackageManager) error {
+		pkgs, err := pm.ListInstalled(ctx)
+		if err != nil {
+			return err
+		}
+
+		// The version is informational, so a failure only leaves it out
+		version, _ := pm.Version(ctx)
+
+		entries := make([]envfile.Package, 0, len(pkgs))
+		for _, pkg := range pkgs {
+			entries = append(entries, envfile.Package{
+				Name:    pkg.Name,
+				Version: pkg.Version,
+				Scope:   pkg.Scope,
+				Source:  pkg.Source,
+			})
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		if version != "" {
+			env.Metadata.Managers[pm.GetName()] = version
+		}
+		env.Packages[pm.GetName()] = entries
+		return nil
+	})
+
+	env.Sort()
+	return env, errs
+}
+
+func NewExportCmd() *cobra.Command {
+	var (
+		file      string
+		providers []string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export installed packages to a portable environment file",
+		Long: `Export the packages installed by every available package manager to an
+environment file that can be committed, reviewed and restored with "ppm import".
+
+The file records each package's version, install scope and source grouped by
+package manager, along with the operating system, architecture and package
+manager versions it was taken on. It is written as YAML unless the file ends
+in ".json"; use "-o -" to print it.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			mgr := newManager()
+
+			pms, err := selectManagers(mgr, providers)
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := operationContext(cmd)
+			defer cancel()
+
+			var (
+				env  *envfile.File
+				errs map[string]error
+			)
+			err = runWithSpinner(ctx, "Collecting installed packages...", func(ctx context.Context) error {
+				env, errs = captureEnvironment(ctx, pms)
+				return ctx.Err()
+			})
+			if err != nil {
+				return err
+			}
+
+			warnProviderErrors("export", errs)
+
+			if len(env.Packages) == 0 {
+				return fmt.Errorf("no available package manager could list its packages")
+			}
+
+			if file == "-" {
+				return envfile.Encode(os.Stdout, env, envfile.YAML)
+			}
+
+			f, err := os.Create(file)
+			if err != nil {
+				return fmt.Errorf("failed to create %s: %v", file, err)
+			}
+			if err := envfile.Encode(f, env, envfile.FormatForPath(file)); err != nil {
+				f.Close()
+				return fmt.Errorf("failed to write %s: %v", file, err)
+			}
+			if err := f.Close(); err != nil {
+				return fmt.Errorf("failed to write %s: %v", file, err)
+			}
+
+			fmt.Printf("✓ Exported %d packages from %s to %s\n", env.Count(), strings.Join(env.Providers(), ", "), file)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&file, "file", "o", envfile.DefaultPath, `file to write, "-" for standard output`)
+	cmd.Flags().StringSliceVarP(&providers, "provider", "p", nil, "only export packages from these package managers (npm, pip, scoop)")
+
+	return cmd
+}
@@ -0,0 +1,284 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"sync"
+
+	"github.com/RichestHumanAlive/ppm_cli/pkg/envfile"
+	"github.com/RichestHumanAlive/ppm_cli/pkg/lockfile"
+	"github.com/RichestHumanAlive/ppm_cli/pkg/manager"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+)
+
+// importResult is the outcome of applying one step of an import plan
+type importResult struct {
+	step envfile.Step
+	err  error
+}
+
+// planImport diffs every package manager section of env against the host.
+// Sections of package managers that are unknown, unsupported on this OS or
+// not installed become blocked steps rather than errors.
+func planImport(ctx context.Context, mgr *manager.Manager, env *envfile.File) []envfile.Step {
+	var (
+		mu    sync.Mutex
+		wg    sync.WaitGroup
+		steps []envfile.Step
+	)
+
+	add := func(s []envfile.Step) {
+		mu.Lock()
+		steps = append(steps, s...)
+		mu.Unlock()
+	}
+
+	for _, provider := range env.Providers() {
+		want := env.Packages[provider]
+
+		pm, ok := mgr.GetManager(provider)
+		if !ok {
+			add(envfile.Blocked(provider, want, envfile.Unsupported, "unknown package manager"))
+			continue
+		}
+		if !manager.SupportsOS(pm, runtime.GOOS) {
+			add(envfile.Blocked(provider, want, envfile.Unsupported, fmt.Sprintf("%s does not run on %s", provider, runtime.GOOS)))
+			continue
+		}
+
+		wg.Add(1)
+		go func(provider string, pm manager.PackageManager, want []envfile.Package) {
+			defer wg.Done()
+			if !pm.IsAvailable(ctx) {
+				add(envfile.Blocked(provider, want, envfile.Unavailable, fmt.Sprintf("%s is not installed", provider)))
+				return
+			}
+			installed, err := pm.ListInstalled(ctx)
+			if err != nil {
+				add(envfile.Blocked(provider, want, envfile.Unavailable, "could not list installed packages: "+firstLine(err.Error())))
+				return
+			}
+			add(envfile.Plan(provider, want, installed))
+		}(provider, pm, want)
+	}
+	wg.Wait()
+
+	envfile.SortSteps(steps)
+	return steps
+}
+
+// warnBlockedProviders prints one warning per package manager whose packages
+// cannot be imported on this host
+func warnBlockedProviders(steps []envfile.Step) {
+	counts := make(map[string]int)
+	reasons := make(map[string]string)
+	order := make([]string, 0)
+	for _, s := range steps {
+		if s.Action != envfile.Unsupported && s.Action != envfile.Unavailable {
+			continue
+		}
+		if counts[s.Provider] == 0 {
+			order = append(order, s.Provider)
+			reasons[s.Provider] = s.Reason
+		}
+		counts[s.Provider]++
+	}
+
+	for _, provider := range order {
+		fmt.Fprintf(os.Stderr, "warning: skipping %d %s packages: %s\n", counts[provider], provider, reasons[provider])
+	}
+}
+
+func actionStyle(action envfile.Action) lipgloss.Style {
+	switch action {
+	case envfile.Install:
+		return successStyle
+	case envfile.Upgrade:
+		return versionStyle
+	case envfile.Skip:
+		return mutedStyle
+	default:
+		return errorStyle
+	}
+}
+
+func renderImportPlan(steps []envfile.Step) string {
+	rows := make([][]string, 0, len(steps))
+	for _, s := range steps {
+		rows = append(rows, []string{string(s.Action), s.Provider, s.Name, s.Installed, s.Target, s.Reason})
+	}
+
+	return renderTable([]column{
+		{header: "Action", rowStyle: func(row int) lipgloss.Style { return actionStyle(steps[row].Action) }},
+		{header: "Provider", style: providerStyle},
+		{header: "Package", style: titleStyle},
+		{header: "Installed", style: versionStyle},
+		{header: "Target", style: versionStyle},
+		{header: "Note", style: descStyle, overflow: overflowWrap},
+	}, rows)
+}
+
+func renderImportReport(results []importResult) string {
+	rows := make([][]string, 0, len(results))
+	for _, r := range results {
+		status, details := "done", ""
+		if r.err != nil {
+			status, details = "failed", firstLine(r.err.Error())
+		}
+		rows = append(rows, []string{string(r.step.Action), r.step.Provider, r.step.Name, r.step.Target, status, details})
+	}
+
+	return renderTable([]column{
+		{header: "Action", rowStyle: func(row int) lipgloss.Style { return actionStyle(results[row].step.Action) }},
+		{header: "Provider", style: providerStyle},
+		{header: "Package", style: titleStyle},
+		{header: "Version", style: versionStyle},
+		{header: "Status", rowStyle: func(row int) lipgloss.Style { return resultStyle(results[row].err) }},
+		{header: "Details", style: descStyle, overflow: overflowWrap},
+	}, rows)
+}
+
+// applyImport installs the packages of every pending step at the version
+// recorded in the file, one at a time
+func applyImport(ctx context.Context, mgr *manager.Manager, steps []envfile.Step) ([]importResult, error) {
+	pending := make([]envfile.Step, 0, len(steps))
+	for _, s := range steps {
+		if s.Pending() {
+			pending = append(pending, s)
+		}
+	}
+
+	results := make([]importResult, 0, len(pending))
+	for i, s := range pending {
+		pm, _ := mgr.GetManager(s.Provider)
+		spec := manager.PackageSpec{Name: s.Name, Constraint: s.Target}
+
+		message := fmt.Sprintf("[%d/%d] %s %s with %s...", i+1, len(pending), verbing(s.Action), spec, s.Provider)
+		err := runWithSpinner(ctx, message, func(ctx context.Context) error {
+			return pm.Install(ctx, spec)
+		})
+		if ctx.Err() != nil || errors.Is(err, errInterrupted) || errors.Is(err, errTimedOut) {
+			// Interrupted: report what was done so far
+			return results, err
+		}
+		results = append(results, importResult{step: s, err: err})
+	}
+	return results, nil
+}
+
+// verbing returns the progressive form of an action for progress messages
+func verbing(action envfile.Action) string {
+	if action == envfile.Upgrade {
+		return "Upgrading"
+	}
+	return "Installing"
+}
+
+func NewImportCmd() *cobra.Command {
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "import <file>",
+		Short: "Recreate an environment from a file written by ppm export",
+		Long: `Recreate an environment from a file written by "ppm export".
+
+The file is compared with the packages installed on this host and a plan is
+shown: packages are installed, upgraded to the recorded version, or skipped
+when they are already installed at that version or newer. Packages of package
+managers that do not run on this operating system or are not installed are
+reported and left out. Nothing changes until the plan is confirmed.
+
+When ppm.lock exists in the working directory, its versions take precedence
+over the ones recorded in the file.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			env, err := envfile.Load(args[0])
+			if err != nil {
+				return err
+			}
+
+			lock, err := lockfile.LoadIfExists(lockfile.DefaultPath)
+			if err != nil {
+				return err
+			}
+			withLockedVersions(env, lock)
+
+			if env.Metadata.OS != "" && (env.Metadata.OS != runtime.GOOS || env.Metadata.Arch != runtime.GOARCH) {
+				fmt.Fprintf(os.Stderr, "note: %s was exported on %s/%s\n", args[0], env.Metadata.OS, env.Metadata.Arch)
+			}
+
+			mgr := newManager()
+
+			ctx, cancel := operationContext(cmd)
+			defer cancel()
+
+			var steps []envfile.Step
+			err = runWithSpinner(ctx, "Comparing with installed packages...", func(ctx context.Context) error {
+				steps = planImport(ctx, mgr, env)
+				return ctx.Err()
+			})
+			if err != nil {
+				return err
+			}
+
+			if len(steps) == 0 {
+				fmt.Println("The environment file lists no packages")
+				return nil
+			}
+
+			pending := 0
+			for _, s := range steps {
+				if s.Pending() {
+					pending++
+				}
+			}
+
+			fmt.Printf("\nImport plan for %s\n\n", args[0])
+			fmt.Print(renderImportPlan(steps))
+			warnBlockedProviders(steps)
+
+			if pending == 0 {
+				fmt.Println("\nNothing to do: every importable package is already installed")
+				return nil
+			}
+			if dryRun {
+				fmt.Printf("\n%d changes planned (dry run, nothing was changed)\n", pending)
+				return nil
+			}
+
+			if err := confirmChanges(pending); err != nil {
+				return err
+			}
+
+			results, err := applyImport(ctx, mgr, steps)
+			if len(results) > 0 {
+				fmt.Printf("\nImport report\n\n")
+				fmt.Print(renderImportReport(results))
+			}
+			if err != nil {
+				return err
+			}
+
+			failed := 0
+			for _, r := range results {
+				if r.err != nil {
+					failed++
+				}
+			}
+			if failed > 0 {
+				return fmt.Errorf("%d of %d changes failed", failed, len(results))
+			}
+
+			fmt.Printf("\n✓ Applied %d changes\n", len(results))
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "only print the plan")
+
+	return cmd
+}
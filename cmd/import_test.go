@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/RichestHumanAlive/ppm_cli/pkg/envfile"
+	"github.com/RichestHumanAlive/ppm_cli/pkg/manager"
+	"github.com/spf13/cobra"
+)
+
+// stubManager is a PackageManager that records what it is asked to change
+// and runs change for each of them
+type stubManager struct {
+	name      string
+	installed []string // Packages IsInstalled reports
+	changed   []string
+	change    func(ctx context.Context) error
+}
+
+func (s *stubManager) run(ctx context.Context, name string) error {
+	s.changed = append(s.changed, name)
+	if s.change == nil {
+		return nil
+	}
+	return s.change(ctx)
+}
+
+func (s *stubManager) Install(ctx context.Context, spec manager.PackageSpec) error {
+	return s.run(ctx, spec.Name)
+}
+func (s *stubManager) Remove(ctx context.Context, pkg string) error { return s.run(ctx, pkg) }
+func (s *stubManager) Update(ctx context.Context, pkg string) error { return s.run(ctx, pkg) }
+func (s *stubManager) Search(ctx context.Context, query string) ([]manager.Package, error) {
+	return nil, nil
+}
+func (s *stubManager) ListInstalled(ctx context.Context) ([]manager.Package, error) {
+	return nil, nil
+}
+func (s *stubManager) ListOutdated(ctx context.Context) ([]manager.OutdatedPackage, error) {
+	return nil, nil
+}
+func (s *stubManager) IsInstalled(ctx context.Context, pkg string) bool {
+	for _, name := range s.installed {
+		if name == pkg {
+			return true
+		}
+	}
+	return false
+}
+func (s *stubManager) IsAvailable(ctx context.Context) bool        { return true }
+func (s *stubManager) GetName() string                             { return s.name }
+func (s *stubManager) Version(ctx context.Context) (string, error) { return "1.0.0", nil }
+
+// interruptions are the ways a package manager command can be interrupted:
+// Ctrl-C in the spinner, which interrupts the command's context, and an
+// interruption only the spinner's own context saw
+var interruptions = map[string]func(ctx context.Context) error{
+	"command": func(ctx context.Context) error {
+		interrupt(ctx)
+		return ctx.Err()
+	},
+	"spinner": func(ctx context.Context) error {
+		return errInterrupted
+	},
+}
+
+// stubCommandContext returns the context of a command operation in a
+// non-interactive session
+func stubCommandContext(t *testing.T) context.Context {
+	t.Helper()
+	saved := noInput
+	noInput = true
+	t.Cleanup(func() { noInput = saved })
+
+	cmd := &cobra.Command{}
+	cmd.SetContext(context.Background())
+	ctx, cancel := operationContext(cmd)
+	t.Cleanup(cancel)
+	return ctx
+}
+
+func TestApplyImportStopsWhenInterrupted(t *testing.T) {
+	for name, change := range interruptions {
+		t.Run(name, func(t *testing.T) {
+			pm := &stubManager{name: "npm", change: change}
+			mgr := manager.New()
+			mgr.RegisterManager(pm)
+
+			steps := []envfile.Step{
+				{Provider: "npm", Name: "prettier", Target: "3.2.5", Action: envfile.Install},
+				{Provider: "npm", Name: "eslint", Target: "8.57.0", Action: envfile.Install},
+			}
+			results, err := applyImport(stubCommandContext(t), mgr, steps)
+			if !errors.Is(err, errInterrupted) {
+				t.Errorf("applyImport() error = %v, want %v", err, errInterrupted)
+			}
+			if len(results) != 0 || len(pm.changed) != 1 {
+				t.Errorf("applyImport() installed %v with results %v, want it to stop after prettier", pm.changed, results)
+			}
+		})
+	}
+}
@@ -0,0 +1,260 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/RichestHumanAlive/ppm_cli/pkg/manager"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// infoReleases and infoDependencies cap the lists of the info view
+	infoReleases     = 5
+	infoDependencies = 8
+
+	// infoCardWidth is the narrowest an info card gets before cards are
+	// stacked instead of shown side by side
+	infoCardWidth = 36
+)
+
+// infoProviders returns the package managers that can describe the spec's
+// package: the one named by its prefix, or every one running on this OS
+func infoProviders(mgr *manager.Manager, spec manager.PackageSpec) ([]manager.PackageManager, error) {
+	if spec.Provider != "" {
+		pm, ok := mgr.GetManager(spec.Provider)
+		if !ok {
+			return nil, fmt.Errorf("unknown package manager: %s", spec.Provider)
+		}
+		if _, ok := pm.(manager.InfoProvider); !ok {
+			return nil, fmt.Errorf("%s cannot describe packages", spec.Provider)
+		}
+		return []manager.PackageManager{pm}, nil
+	}
+
+	pms := make([]manager.PackageManager, 0, len(mgr.GetManagers()))
+	for _, pm := range mgr.GetManagers() {
+		if _, ok := pm.(manager.InfoProvider); ok && manager.SupportsOS(pm, runtime.GOOS) {
+			pms = append(pms, pm)
+		}
+	}
+	return pms, nil
+}
+
+// lookupInfo describes name with every package manager in pms concurrently,
+// along with the installed version where the package manager has it
+// installed. Results are in the order of pms; package managers that do not
+// publish name are left out and failing ones are reported by name.
+func lookupInfo(ctx context.Context, pms []manager.PackageManager, name string) ([]manager.PackageInfo, map[string]error) {
+	found := make([]*manager.PackageInfo, len(pms))
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		errs = make(map[string]error)
+	)
+
+	for i, pm := range pms {
+		wg.Add(1)
+		go func(i int, pm manager.PackageManager) {
+			defer wg.Done()
+
+			info, err := pm.(manager.InfoProvider).GetInfo(ctx, name)
+			if _, ok := err.(*manager.NotFoundError); ok {
+				return
+			}
+			if err != nil {
+				mu.Lock()
+				errs[pm.GetName()] = err
+				mu.Unlock()
+				return
+			}
+
+			if pm.IsAvailable(ctx) {
+				installed, _ := pm.ListInstalled(ctx)
+				for _, pkg := range installed {
+					if manager.SameName(pm.GetName(), pkg.Name, info.Name) {
+						info.Installed = pkg.Version
+					}
+				}
+			}
+			found[i] = &info
+		}(i, pm)
+	}
+	wg.Wait()
+
+	infos := make([]manager.PackageInfo, 0, len(pms))
+	for _, info := range found {
+		if info != nil {
+			infos = append(infos, *info)
+		}
+	}
+	return infos, errs
+}
+
+// renderInfo renders one card per package manager, side by side when the
+// terminal is wide enough
+func renderInfo(infos []manager.PackageInfo) string {
+	width := terminalWidth()
+	if width == 0 {
+		width = 100
+	}
+
+	perRow := len(infos)
+	for perRow > 1 && width/perRow < infoCardWidth {
+		perRow--
+	}
+	cardWidth := width/perRow - 1
+
+	var rows []string
+	for start := 0; start < len(infos); start += perRow {
+		end := start + perRow
+		if end > len(infos) {
+			end = len(infos)
+		}
+		cards := make([]string, 0, perRow)
+		for _, info := range infos[start:end] {
+			cards = append(cards, renderInfoCard(info, cardWidth))
+		}
+		rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Top, cards...))
+	}
+	return strings.Join(rows, "\n") + "\n"
+}
+
+// renderInfoCard renders the details of a package as a bordered card of the
+// given width
+func renderInfoCard(info manager.PackageInfo, width int) string {
+	// Border and padding take 4 cells
+	inner := width - 4
+	const labelWidth = 13
+	valueStyle := lipgloss.NewStyle().Width(inner - labelWidth)
+
+	var lines []string
+	field := func(label, value string) {
+		if value == "" {
+			value = mutedStyle.Render("-")
+		}
+		lines = append(lines, lipgloss.JoinHorizontal(lipgloss.Top,
+			mutedStyle.Render(padRight(label, labelWidth)), valueStyle.Render(value)))
+	}
+
+	lines = append(lines,
+		titleStyle.Render(info.Name)+" "+versionStyle.Render(info.Version)+" "+providerStyle.Render(info.Provider),
+		lipgloss.NewStyle().Width(inner).Render(descStyle.Render(info.Description)),
+		"",
+	)
+
+	installed := mutedStyle.Render("not installed")
+	if info.Installed != "" {
+		installed = successStyle.Render("✓ " + info.Installed)
+	}
+	field("Installed", installed)
+	field("License", info.License)
+	field("Author", info.Author)
+	field("Maintainers", strings.Join(info.Maintainers, ", "))
+	field("Homepage", info.Homepage)
+	field("Repository", info.Repository)
+	if info.Source != "" {
+		field("Source", info.Source)
+	}
+
+	releases := make([]string, 0, infoReleases+1)
+	for i, r := range info.Releases {
+		if i == infoReleases {
+			releases = append(releases, mutedStyle.Render(fmt.Sprintf("%d versions in total", len(info.Releases))))
+			break
+		}
+		line := versionStyle.Render(r.Version)
+		if r.Date != "" {
+			line += " " + mutedStyle.Render(r.Date)
+		}
+		releases = append(releases, line)
+	}
+	field("Versions", strings.Join(releases, "\n"))
+
+	deps := make([]string, 0, infoDependencies+1)
+	for i, dep := range info.Dependencies {
+		if i == infoDependencies {
+			deps = append(deps, mutedStyle.Render(fmt.Sprintf("and %d more", len(info.Dependencies)-infoDependencies)))
+			break
+		}
+		deps = append(deps, dep)
+	}
+	if len(deps) == 0 {
+		deps = append(deps, mutedStyle.Render("none"))
+	}
+	field("Dependencies", strings.Join(deps, "\n"))
+
+	return detailStyle.Width(width - 2).Render(strings.Join(lines, "\n"))
+}
+
+func NewInfoCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "info <package>",
+		Short: "Show the details of a package in every package manager that publishes it",
+		Long: `Show the details of a package: its published versions and their release
+dates, the dependencies of the latest version, license, maintainers, homepage,
+repository and whether it is installed.
+
+Every package manager that publishes the name is shown, side by side when the
+terminal is wide enough. Prefix the name with a package manager, e.g.
+"ppm info pip:black", to only look there.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := getOutputFormat(cmd)
+			if err != nil {
+				return err
+			}
+
+			spec, err := parseSpecArg(args[0], false)
+			if err != nil {
+				return err
+			}
+
+			mgr := newManager()
+			pms, err := infoProviders(mgr, spec)
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := operationContext(cmd)
+			defer cancel()
+
+			var (
+				infos []manager.PackageInfo
+				errs  map[string]error
+			)
+			err = runWithStatus(ctx, format, fmt.Sprintf("Looking up '%s'...", spec.Name), func(ctx context.Context) error {
+				infos, errs = lookupInfo(ctx, pms, spec.Name)
+				return ctx.Err()
+			})
+			if err != nil {
+				return err
+			}
+
+			warnProviderErrors("look up", errs)
+			if len(infos) == 0 {
+				if len(errs) > 0 {
+					return fmt.Errorf("could not look up %s", spec.Name)
+				}
+				if spec.Provider != "" {
+					return &manager.NotFoundError{Provider: spec.Provider, Name: spec.Name}
+				}
+				return fmt.Errorf("no package named %s found with any package manager", spec.Name)
+			}
+
+			if format.structured() {
+				return writeStructured(os.Stdout, format, infos)
+			}
+			fmt.Println()
+			fmt.Print(renderInfo(infos))
+			return nil
+		},
+	}
+
+	return cmd
+}
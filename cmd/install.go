@@ -1,113 +1,168 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"os"
 
+	"github.com/RichestHumanAlive/ppm_cli/pkg/lockfile"
 	"github.com/RichestHumanAlive/ppm_cli/pkg/manager"
-	"github.com/RichestHumanAlive/ppm_cli/pkg/manager/npm"
-	"github.com/RichestHumanAlive/ppm_cli/pkg/manager/pip"
-	"github.com/RichestHumanAlive/ppm_cli/pkg/manager/scoop"
-	"github.com/charmbracelet/bubbles/spinner"
-	tea "github.com/charmbracelet/bubbletea"
 	"github.com/spf13/cobra"
 )
 
-type model struct {
-	spinner  spinner.Model
-	quitting bool
+// defaultJobs is how many packages "ppm install" installs at the same time
+const defaultJobs = 4
+
+// checkJobs rejects a --jobs value that would install nothing
+func checkJobs(jobs int) error {
+	if jobs < 1 {
+		return UsageError(fmt.Errorf("--jobs must be at least 1, got %d", jobs))
+	}
+	return nil
 }
 
-func (m model) Init() tea.Cmd {
-	return m.spinner.Tick
+// installTarget is a package spec resolved to the package manager to install it with
+type installTarget struct {
+	pm   manager.PackageManager
+	spec manager.PackageSpec
 }
 
-func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	switch msg := msg.(type) {
-	case tea.KeyMsg:
-		if msg.String() == "q" {
-			m.quitting = true
-			return m, tea.Quit
+// resolveInstallTargets parses each argument and picks the package manager
+// to install it with, asking the user when several publish it. Arguments
+// that cannot be resolved are returned as errors by argument.
+func resolveInstallTargets(ctx context.Context, mgr *manager.Manager, lock *lockfile.File, args []string) ([]installTarget, map[string]error) {
+	targets := make([]installTarget, 0, len(args))
+	failures := make(map[string]error)
+
+	for _, arg := range args {
+		spec, err := manager.ParseSpec(arg)
+		if err != nil {
+			failures[arg] = err
+			continue
 		}
-	default:
-		var cmd tea.Cmd
-		m.spinner, cmd = m.spinner.Update(msg)
-		return m, cmd
-	}
-	return m, nil
-}
+		spec = applyLock(lock, spec)
 
-func (m model) View() string {
-	if m.quitting {
-		return ""
+		pm, err := resolvePublished(ctx, mgr, spec)
+		if err != nil {
+			if ctx.Err() != nil {
+				failures[arg] = interruptedError(ctx, err)
+				break
+			}
+			failures[arg] = err
+			continue
+		}
+		targets = append(targets, installTarget{pm: pm, spec: spec})
 	}
-	return fmt.Sprintf("\n %s Installing package...\n", m.spinner.View())
+
+	return targets, failures
 }
 
 func NewInstallCmd() *cobra.Command {
+	var jobs int
+
 	cmd := &cobra.Command{
-		Use:   "install [package]",
-		Short: "Install a package using the appropriate package manager",
-		Args:  cobra.MinimumNArgs(1),
+		Use:   "install <package>...",
+		Short: "Install packages using the appropriate package managers",
+		Long: `Install packages using the appropriate package managers.
+
+Each package may carry a version constraint and a package manager prefix:
+
+  ppm install lodash@4.17.21
+  ppm install "requests>=2.31"
+  ppm install npm:typescript@^5 pip:black scoop:git
+
+Without a prefix, PPM looks the name up in every available package manager
+and asks which one to use when several publish it. In non-interactive
+sessions an ambiguous name is an error; add a prefix to pick one.
+
+Packages are installed concurrently, --jobs at a time. Package managers that
+cannot run several operations at once (pip, scoop) install their packages
+one after the other.
+
+Constraints are translated to each package manager's syntax; a constraint a
+package manager cannot express (e.g. a range for scoop) is reported as an
+error.
+
+When ppm.lock exists in the working directory, packages without a constraint
+are installed at their locked version.`,
+		Args: cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			pkg := args[0]
+			if err := checkJobs(jobs); err != nil {
+				return err
+			}
+
+			lock, err := lockfile.LoadIfExists(lockfile.DefaultPath)
+			if err != nil {
+				return err
+			}
 
 			// Initialize manager
-			mgr := manager.New()
-			mgr.RegisterManager(npm.New())
-			mgr.RegisterManager(pip.New())
-			mgr.RegisterManager(scoop.New())
-
-			// Create a channel to receive installation result
-			errChan := make(chan error)
-
-			// Create and start spinner
-			s := spinner.New()
-			s.Spinner = spinner.Dot
-			s.Style = s.Style.Foreground(s.Style.GetForeground())
-
-			m := model{spinner: s}
-			p := tea.NewProgram(m)
-
-			// Start installation in goroutine
-			go func() {
-				// Try each package manager
-				var installErr error
-				for _, pm := range mgr.GetManagers() {
-					if pm.IsAvailable() {
-						if err := pm.Install(pkg); err == nil {
-							errChan <- nil
-							return
-						} else {
-							installErr = err
-						}
+			mgr := newManager()
+
+			ctx, cancel := operationContext(cmd)
+			defer cancel()
+
+			targets, failures := resolveInstallTargets(ctx, mgr, lock, args)
+			if len(args) > 1 {
+				// A single argument's failure is the command's error
+				for _, arg := range args {
+					if err, ok := failures[arg]; ok {
+						fmt.Fprintf(os.Stderr, "error: %s: %v\n", arg, err)
 					}
 				}
-				if installErr != nil {
-					errChan <- fmt.Errorf("no package manager could install %s: %v", pkg, installErr)
-				} else {
-					errChan <- fmt.Errorf("no available package manager found")
-				}
-			}()
+			}
+			if ctx.Err() != nil {
+				return interruptedError(ctx, ctx.Err())
+			}
 
-			// Start spinner
-			go func() {
-				if err := p.Start(); err != nil {
-					fmt.Printf("Error starting spinner: %v\n", err)
-				}
-			}()
+			tasks := make([]task, 0, len(targets))
+			for _, t := range targets {
+				t := t
+				tasks = append(tasks, task{
+					label: fmt.Sprintf("%s with %s", t.spec, t.pm.GetName()),
+					pm:    t.pm,
+					run:   func(ctx context.Context) error { return t.pm.Install(ctx, t.spec) },
+				})
+			}
 
-			// Wait for installation result
-			err := <-errChan
-			p.Quit()
+			var errs []error
+			if len(tasks) > 0 {
+				errs = runTasks(ctx, tasks, jobs)
+			}
+			if ctx.Err() != nil {
+				return interruptedError(ctx, ctx.Err())
+			}
 
-			if err != nil {
-				return fmt.Errorf("installation failed: %v", err)
+			installed := 0
+			for i, err := range errs {
+				switch {
+				case err == nil:
+					installed++
+				case isInterrupted(err):
+					return err
+				case len(args) == 1:
+					return fmt.Errorf("installation failed: %v", err)
+				default:
+					fmt.Fprintf(os.Stderr, "\nerror: installing %s failed: %v\n", tasks[i].label, err)
+				}
+			}
+			if failed := len(args) - installed; failed > 0 {
+				if len(args) == 1 {
+					return failures[args[0]]
+				}
+				return fmt.Errorf("%d of %d packages failed to install", failed, len(args))
 			}
 
-			fmt.Printf("\n✓ Successfully installed %s\n", pkg)
+			if len(targets) == 1 {
+				fmt.Printf("\n✓ Successfully installed %s with %s\n", targets[0].spec, targets[0].pm.GetName())
+			} else {
+				fmt.Printf("\n✓ Successfully installed %d packages\n", installed)
+			}
 			return nil
 		},
 	}
 
+	cmd.Flags().IntVarP(&jobs, "jobs", "j", defaultJobs, "how many packages to install at the same time")
+
 	return cmd
 }
@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var (
+	assumeYes bool // --yes: answer confirmation prompts with yes
+	noInput   bool // --no-input: never prompt or show interactive views
+)
+
+// AddPromptFlags registers the global flags that control prompting on the
+// root command
+func AddPromptFlags(root *cobra.Command) {
+	root.PersistentFlags().BoolVarP(&assumeYes, "yes", "y", false, "answer yes to confirmation prompts")
+	root.PersistentFlags().BoolVar(&noInput, "no-input", false, "never prompt or show interactive views (implied by CI=true and when not in a terminal)")
+}
+
+// isInteractive reports whether PPM can prompt the user and draw interactive
+// views: both standard input and output are terminals, and neither --no-input
+// nor a CI environment asks for plain output
+func isInteractive() bool {
+	return !noInput && !inCI() &&
+		term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// inCI reports whether PPM runs in a CI job, which CI services announce by
+// setting CI=true
+func inCI() bool {
+	ci, _ := strconv.ParseBool(os.Getenv("CI"))
+	return ci
+}
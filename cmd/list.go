@@ -0,0 +1,228 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/RichestHumanAlive/ppm_cli/pkg/manager"
+	"github.com/spf13/cobra"
+)
+
+// selectManagers returns the registered package managers whose names are in
+// providers, or all of them when providers is empty
+func selectManagers(mgr *manager.Manager, providers []string) ([]manager.PackageManager, error) {
+	if len(providers) == 0 {
+		return mgr.GetManagers(), nil
+	}
+
+	pms := make([]manager.PackageManager, 0, len(providers))
+	for _, name := range providers {
+		pm, ok := mgr.GetManager(name)
+		if !ok {
+			return nil, UsageError(fmt.Errorf("unknown package manager: %s", name))
+		}
+		pms = append(pms, pm)
+	}
+	return pms, nil
+}
+
+// forEachAvailable calls fn concurrently for every available package manager
+// in pms and collects the errors it returns by package manager name
+func forEachAvailable(ctx context.Context, pms []manager.PackageManager, fn func(pm manager.PackageManager) error) map[string]error {
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		errs = make(map[string]error)
+	)
+
+	for _, pm := range pms {
+		wg.Add(1)
+		go func(pm manager.PackageManager) {
+			defer wg.Done()
+			if !pm.IsAvailable(ctx) {
+				return
+			}
+			if err := fn(pm); err != nil {
+				mu.Lock()
+				errs[pm.GetName()] = err
+				mu.Unlock()
+			}
+		}(pm)
+	}
+	wg.Wait()
+
+	return errs
+}
+
+// listInstalled gathers installed packages from every available package
+// manager in pms. Failing managers are reported in the returned map instead of
+// aborting the whole listing.
+func listInstalled(ctx context.Context, pms []manager.PackageManager) ([]manager.Package, map[string]error) {
+	var (
+		mu       sync.Mutex
+		packages []manager.Package
+	)
+
+	errs := forEachAvailable(ctx, pms, func(pm manager.PackageManager) error {
+		pkgs, err := pm.ListInstalled(ctx)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		packages = append(packages, pkgs...)
+		mu.Unlock()
+		return nil
+	})
+
+	return packages, errs
+}
+
+// warnProviderErrors prints one warning per failed package manager to stderr
+func warnProviderErrors(action string, errs map[string]error) {
+	names := make([]string, 0, len(errs))
+	for name := range errs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "warning: could not %s %s packages: %v\n", action, name, firstLine(errs[name].Error()))
+	}
+}
+
+// sortFields are the fields list can sort packages by
+var sortFields = []string{"name", "provider", "version", "source"}
+
+// checkSortField rejects a --sort value sortPackages does not know
+func checkSortField(by string) error {
+	for _, field := range sortFields {
+		if by == field {
+			return nil
+		}
+	}
+	return UsageError(fmt.Errorf("invalid sort field %q (expected %s)", by, strings.Join(sortFields, ", ")))
+}
+
+// sortPackages orders packages by the given field, one of sortFields, falling
+// back to name and provider so the output is stable. Versions are compared as
+// versions, so 1.10.0 sorts after 1.9.0.
+func sortPackages(pkgs []manager.Package, by string) {
+	key := func(p manager.Package) string {
+		switch by {
+		case "provider":
+			return p.Provider
+		case "version":
+			return p.Version
+		case "source":
+			return p.Source
+		default:
+			return strings.ToLower(p.Name)
+		}
+	}
+
+	sort.SliceStable(pkgs, func(i, j int) bool {
+		if by == "version" {
+			if c := manager.CompareVersions(pkgs[i].Version, pkgs[j].Version); c != 0 {
+				return c < 0
+			}
+		}
+		if ki, kj := key(pkgs[i]), key(pkgs[j]); ki != kj {
+			return ki < kj
+		}
+		if ni, nj := strings.ToLower(pkgs[i].Name), strings.ToLower(pkgs[j].Name); ni != nj {
+			return ni < nj
+		}
+		return pkgs[i].Provider < pkgs[j].Provider
+	})
+}
+
+func NewListCmd() *cobra.Command {
+	var (
+		providers   []string
+		filter      string
+		sortBy      string
+		columnNames []string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List installed packages across all package managers",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := getOutputFormat(cmd)
+			if err != nil {
+				return err
+			}
+			cols, err := selectPackageColumns(columnNames)
+			if err != nil {
+				return err
+			}
+			if err := checkSortField(sortBy); err != nil {
+				return err
+			}
+
+			mgr := newManager()
+
+			pms, err := selectManagers(mgr, providers)
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := operationContext(cmd)
+			defer cancel()
+
+			var (
+				pkgs []manager.Package
+				errs map[string]error
+			)
+			err = runWithStatus(ctx, format, "Listing installed packages...", func(ctx context.Context) error {
+				pkgs, errs = listInstalled(ctx, pms)
+				return ctx.Err()
+			})
+			if err != nil {
+				return err
+			}
+
+			warnProviderErrors("list", errs)
+
+			if filter != "" {
+				filtered := pkgs[:0]
+				for _, pkg := range pkgs {
+					if strings.Contains(strings.ToLower(pkg.Name), strings.ToLower(filter)) {
+						filtered = append(filtered, pkg)
+					}
+				}
+				pkgs = filtered
+			}
+
+			sortPackages(pkgs, sortBy)
+
+			if format.structured() {
+				if pkgs == nil {
+					pkgs = []manager.Package{}
+				}
+				return writeStructured(os.Stdout, format, pkgs)
+			}
+
+			if len(pkgs) == 0 {
+				fmt.Println("No installed packages found")
+				return nil
+			}
+
+			fmt.Printf("\n%d installed packages\n\n", len(pkgs))
+			fmt.Print(renderPackageTable(pkgs, cols))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringSliceVarP(&providers, "provider", "p", nil, "only list packages from these package managers (npm, pip, scoop)")
+	cmd.Flags().StringVarP(&filter, "filter", "f", "", "only list packages whose name contains this text")
+	cmd.Flags().StringVarP(&sortBy, "sort", "s", "name", "sort by name, provider, version or source")
+	cmd.Flags().StringSliceVar(&columnNames, "columns", []string{"name", "version", "provider", "source"}, columnsUsage())
+
+	return cmd
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/RichestHumanAlive/ppm_cli/pkg/manager"
+)
+
+func TestSortPackagesByVersion(t *testing.T) {
+	pkgs := []manager.Package{
+		{Name: "c", Version: "1.10.0"},
+		{Name: "a", Version: "1.9.0"},
+		{Name: "d", Version: "2.0.0"},
+		{Name: "b", Version: "1.9.0"},
+	}
+	sortPackages(pkgs, "version")
+
+	var got []string
+	for _, pkg := range pkgs {
+		got = append(got, pkg.Name)
+	}
+	if strings.Join(got, " ") != "a b c d" {
+		t.Errorf("sortPackages(version) = %v, want a b c d", got)
+	}
+}
+
+func TestCheckSortField(t *testing.T) {
+	for _, field := range sortFields {
+		if err := checkSortField(field); err != nil {
+			t.Errorf("checkSortField(%s) = %v", field, err)
+		}
+	}
+	if err := checkSortField("size"); ExitCode(err) != ExitUsage {
+		t.Errorf("checkSortField(size) = %v, want a usage error", err)
+	}
+}
+
+func TestSelectManagers(t *testing.T) {
+	mgr := manager.New()
+	mgr.RegisterManager(&stubManager{name: "npm"})
+	mgr.RegisterManager(&stubManager{name: "pip"})
+
+	pms, err := selectManagers(mgr, []string{"pip"})
+	if err != nil || len(pms) != 1 || pms[0].GetName() != "pip" {
+		t.Errorf("selectManagers(pip) = %v, %v", pms, err)
+	}
+	if _, err := selectManagers(mgr, []string{"brew"}); ExitCode(err) != ExitUsage {
+		t.Errorf("selectManagers(brew) = %v, want a usage error", err)
+	}
+}
@@ -0,0 +1,377 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/RichestHumanAlive/ppm_cli/pkg/envfile"
+	"github.com/RichestHumanAlive/ppm_cli/pkg/lockfile"
+	"github.com/RichestHumanAlive/ppm_cli/pkg/manager"
+	"github.com/RichestHumanAlive/ppm_cli/pkg/manifest"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// lockDrift is a locked package whose installed version differs from the lock
+type lockDrift struct {
+	provider  string
+	name      string
+	locked    string
+	installed string // Empty when the package is not installed
+	note      string
+}
+
+// resolveLock pins every declared package to an exact version with the
+// Resolver of its package manager. Packages that cannot be resolved are
+// returned as errors and left out of the lock. Package managers that do not
+// run on this OS are skipped, keeping the versions previous locked for them.
+func resolveLock(ctx context.Context, mgr *manager.Manager, declared map[string][]manager.PackageSpec, previous *lockfile.File) (*lockfile.File, []error) {
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		errs []error
+	)
+	lock := lockfile.New()
+
+	fail := func(err error) {
+		mu.Lock()
+		errs = append(errs, err)
+		mu.Unlock()
+	}
+
+	providers := make([]string, 0, len(declared))
+	for provider := range declared {
+		providers = append(providers, provider)
+	}
+	sort.Strings(providers)
+
+	for _, provider := range providers {
+		pm, ok := mgr.GetManager(provider)
+		if !ok {
+			fail(fmt.Errorf("%s: unknown package manager", provider))
+			continue
+		}
+		if !manager.SupportsOS(pm, runtime.GOOS) {
+			fmt.Fprintf(os.Stderr, "note: not locking %d %s packages on %s\n", len(declared[provider]), provider, runtime.GOOS)
+			if previous != nil {
+				for _, spec := range declared[provider] {
+					if pkg, ok := previous.Find(provider, spec.Name); ok {
+						lock.Packages[provider] = append(lock.Packages[provider], pkg)
+					}
+				}
+			}
+			continue
+		}
+		resolver, ok := pm.(manager.Resolver)
+		if !ok {
+			fail(fmt.Errorf("%s: package manager cannot resolve versions", provider))
+			continue
+		}
+
+		wg.Add(1)
+		go func(provider string, resolver manager.Resolver, specs []manager.PackageSpec) {
+			defer wg.Done()
+			for _, spec := range specs {
+				resolved, err := resolver.Resolve(ctx, spec)
+				if err != nil {
+					fail(fmt.Errorf("%s:%s: %v", provider, spec.Name, firstLine(err.Error())))
+					continue
+				}
+				mu.Lock()
+				lock.Add(provider, resolved)
+				mu.Unlock()
+			}
+		}(provider, resolver, declared[provider])
+	}
+	wg.Wait()
+
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+	return lock, errs
+}
+
+// loadDeclared reads the packages to lock from a project manifest or an
+// environment file, telling them apart by content. Without a path, ppm.yaml
+// is used when it exists and ppm-env.yaml otherwise.
+func loadDeclared(path string) (map[string][]manager.PackageSpec, string, error) {
+	if path == "" {
+		path = envfile.DefaultPath
+		if _, err := os.Stat(manifest.DefaultPath); err == nil {
+			path = manifest.DefaultPath
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, path, err
+	}
+
+	if !isEnvFile(data) {
+		m, err := manifest.Decode(data)
+		if err != nil {
+			return nil, path, fmt.Errorf("%s: %v", path, err)
+		}
+		return m.Packages, path, nil
+	}
+
+	env, err := envfile.Decode(data)
+	if err != nil {
+		return nil, path, fmt.Errorf("%s: %v", path, err)
+	}
+	declared := make(map[string][]manager.PackageSpec, len(env.Packages))
+	for provider, pkgs := range env.Packages {
+		for _, pkg := range pkgs {
+			declared[provider] = append(declared[provider], manager.PackageSpec{Provider: provider, Name: pkg.Name, Constraint: pkg.Version})
+		}
+	}
+	return declared, path, nil
+}
+
+// isEnvFile reports whether data is an environment file rather than a
+// manifest: environment files nest their packages under a "packages" key,
+// manifests list them under each package manager at the top level
+func isEnvFile(data []byte) bool {
+	var doc struct {
+		Packages map[string]interface{} `yaml:"packages"`
+	}
+	return yaml.Unmarshal(data, &doc) == nil && doc.Packages != nil
+}
+
+// checkLock compares the installed packages with the lock. Package managers
+// that do not run on this OS are skipped; missing ones count as drift. A
+// package manager PPM does not know is an error.
+func checkLock(ctx context.Context, mgr *manager.Manager, lock *lockfile.File) ([]lockDrift, error) {
+	var (
+		mu     sync.Mutex
+		drifts []lockDrift
+	)
+
+	providers := make([]string, 0, len(lock.Packages))
+	for provider := range lock.Packages {
+		providers = append(providers, provider)
+	}
+	sort.Strings(providers)
+
+	pms := make([]manager.PackageManager, 0, len(providers))
+	for _, provider := range providers {
+		pm, ok := mgr.GetManager(provider)
+		if !ok {
+			return nil, fmt.Errorf("%s: unknown package manager: %s", lockfile.DefaultPath, provider)
+		}
+		if !manager.SupportsOS(pm, runtime.GOOS) {
+			fmt.Fprintf(os.Stderr, "note: not checking %d %s packages on %s\n", len(lock.Packages[provider]), provider, runtime.GOOS)
+			continue
+		}
+		pms = append(pms, pm)
+	}
+
+	add := func(d lockDrift) {
+		mu.Lock()
+		drifts = append(drifts, d)
+		mu.Unlock()
+	}
+
+	for _, pm := range pms {
+		if !pm.IsAvailable(ctx) {
+			for _, pkg := range lock.Packages[pm.GetName()] {
+				add(lockDrift{provider: pm.GetName(), name: pkg.Name, locked: pkg.Version, note: pm.GetName() + " is not installed"})
+			}
+		}
+	}
+
+	errs := forEachAvailable(ctx, pms, func(pm manager.PackageManager) error {
+		installed, err := pm.ListInstalled(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, pkg := range lock.Packages[pm.GetName()] {
+			d := lockDrift{provider: pm.GetName(), name: pkg.Name, locked: pkg.Version, note: "not installed"}
+			for _, inst := range installed {
+				if manager.SameName(pm.GetName(), inst.Name, pkg.Name) {
+					d.installed, d.note = inst.Version, "version differs"
+					break
+				}
+			}
+			if d.installed == "" || manager.CompareVersions(d.installed, d.locked) != 0 {
+				add(d)
+			}
+		}
+		return nil
+	})
+	for provider, err := range errs {
+		add(lockDrift{provider: provider, name: "*", note: "could not list installed packages: " + firstLine(err.Error())})
+	}
+
+	sort.Slice(drifts, func(i, j int) bool {
+		if drifts[i].provider != drifts[j].provider {
+			return drifts[i].provider < drifts[j].provider
+		}
+		return strings.ToLower(drifts[i].name) < strings.ToLower(drifts[j].name)
+	})
+	return drifts, nil
+}
+
+func renderDriftTable(drifts []lockDrift) string {
+	rows := make([][]string, 0, len(drifts))
+	for _, d := range drifts {
+		rows = append(rows, []string{d.provider, d.name, d.locked, d.installed, d.note})
+	}
+
+	return renderTable([]column{
+		{header: "Provider", style: providerStyle},
+		{header: "Package", style: titleStyle},
+		{header: "Locked", style: versionStyle},
+		{header: "Installed", style: errorStyle},
+		{header: "Note", style: descStyle, overflow: overflowWrap},
+	}, rows)
+}
+
+// applyLock pins an install spec to the lockfile. An unprefixed name locked by
+// exactly one package manager is installed with it, and a spec without a
+// constraint gets the locked version. Explicit constraints win but are
+// reported when they disagree with the lock.
+func applyLock(lock *lockfile.File, spec manager.PackageSpec) manager.PackageSpec {
+	if lock == nil {
+		return spec
+	}
+
+	if spec.Provider == "" {
+		if providers := lock.Providers(spec.Name); len(providers) == 1 {
+			spec.Provider = providers[0]
+		}
+	}
+
+	locked, ok := lock.Find(spec.Provider, spec.Name)
+	if !ok {
+		return spec
+	}
+
+	if spec.Constraint == "" {
+		spec.Constraint = locked.Version
+		fmt.Fprintf(os.Stderr, "note: using %s %s from %s\n", spec.Name, locked.Version, lockfile.DefaultPath)
+	} else if version, exact := spec.ExactVersion(); !exact || version != locked.Version {
+		fmt.Fprintf(os.Stderr, "warning: %s pins %s at %s, installing %s instead\n", lockfile.DefaultPath, spec.Name, locked.Version, spec.Constraint)
+	}
+	return spec
+}
+
+// withLockedVersions replaces the versions recorded in env with the versions
+// locked for the same packages
+func withLockedVersions(env *envfile.File, lock *lockfile.File) {
+	if lock == nil {
+		return
+	}
+	for provider, pkgs := range env.Packages {
+		for i, pkg := range pkgs {
+			if locked, ok := lock.Find(provider, pkg.Name); ok {
+				pkgs[i].Version = locked.Version
+			}
+		}
+	}
+}
+
+func NewLockCmd() *cobra.Command {
+	var (
+		file  string
+		check bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "lock",
+		Short: "Pin declared packages to exact versions in ppm.lock",
+		Long: `Resolve every package declared in the project manifest (ppm.yaml) or, when
+there is none, the environment file (ppm-env.yaml) to an exact version and
+write them to ppm.lock, along with the artifact hashes the registries publish
+(npm dist.integrity, PyPI sha256 digests, scoop manifest hashes). Version
+ranges resolve to the highest published version they allow. The hashes are
+informational: installs pin the locked versions but leave verifying the
+artifacts to the package managers.
+
+Package managers that do not run on this operating system (scoop outside
+Windows) are skipped; their packages keep the versions ppm.lock already has.
+
+"ppm install", "ppm import" and "ppm sync" install the locked versions when ppm.lock
+exists in the working directory. "ppm lock --check" compares the installed
+packages with the lock and fails when they drifted.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			mgr := newManager()
+
+			ctx, cancel := operationContext(cmd)
+			defer cancel()
+
+			if check {
+				lock, err := lockfile.Load(lockfile.DefaultPath)
+				if err != nil {
+					return err
+				}
+
+				var drifts []lockDrift
+				err = runWithSpinner(ctx, "Comparing installed packages with the lock...", func(ctx context.Context) error {
+					var err error
+					if drifts, err = checkLock(ctx, mgr, lock); err != nil {
+						return err
+					}
+					return ctx.Err()
+				})
+				if err != nil {
+					return err
+				}
+
+				if len(drifts) == 0 {
+					fmt.Printf("✓ Installed packages match %s\n", lockfile.DefaultPath)
+					return nil
+				}
+
+				fmt.Printf("\n%d packages drifted from %s\n\n", len(drifts), lockfile.DefaultPath)
+				fmt.Print(renderDriftTable(drifts))
+				return fmt.Errorf("installed packages do not match %s", lockfile.DefaultPath)
+			}
+
+			declared, _, err := loadDeclared(file)
+			if err != nil {
+				return err
+			}
+			previous, err := lockfile.LoadIfExists(lockfile.DefaultPath)
+			if err != nil {
+				return err
+			}
+
+			var (
+				lock *lockfile.File
+				errs []error
+			)
+			err = runWithSpinner(ctx, "Resolving package versions...", func(ctx context.Context) error {
+				lock, errs = resolveLock(ctx, mgr, declared, previous)
+				return ctx.Err()
+			})
+			if err != nil {
+				return err
+			}
+
+			if len(errs) > 0 {
+				for _, err := range errs {
+					fmt.Fprintf(os.Stderr, "error: %v\n", err)
+				}
+				return fmt.Errorf("could not lock %d packages; %s was not written", len(errs), lockfile.DefaultPath)
+			}
+
+			if err := lockfile.Save(lockfile.DefaultPath, lock); err != nil {
+				return fmt.Errorf("failed to write %s: %v", lockfile.DefaultPath, err)
+			}
+
+			fmt.Printf("✓ Locked %d packages in %s\n", lock.Count(), lockfile.DefaultPath)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&file, "file", "f", "", "ppm.yaml or environment file declaring the packages to lock (default ppm.yaml, then ppm-env.yaml)")
+	cmd.Flags().BoolVar(&check, "check", false, "fail if the installed packages drifted from ppm.lock")
+
+	return cmd
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/RichestHumanAlive/ppm_cli/pkg/lockfile"
+	"github.com/RichestHumanAlive/ppm_cli/pkg/manager"
+)
+
+// foreignManager is a stubManager that runs on no operating system
+type foreignManager struct {
+	stubManager
+}
+
+func (f *foreignManager) SupportsOS(goos string) bool { return false }
+
+func TestResolveLockSkipsUnsupportedManagers(t *testing.T) {
+	mgr := manager.New()
+	mgr.RegisterManager(&foreignManager{stubManager{name: "scoop"}})
+
+	previous := lockfile.New()
+	previous.Add("scoop", manager.ResolvedPackage{Name: "git", Version: "2.45.1"})
+	declared := map[string][]manager.PackageSpec{
+		"scoop": {{Provider: "scoop", Name: "git"}, {Provider: "scoop", Name: "7zip"}},
+	}
+
+	lock, errs := resolveLock(context.Background(), mgr, declared, previous)
+	if len(errs) != 0 {
+		t.Fatalf("resolveLock() errors = %v, want none", errs)
+	}
+	if pkg, ok := lock.Find("scoop", "git"); !ok || pkg.Version != "2.45.1" || lock.Count() != 1 {
+		t.Errorf("resolveLock() = %+v, want the previously locked git only", lock.Packages)
+	}
+
+	if lock, errs := resolveLock(context.Background(), mgr, declared, nil); len(errs) != 0 || lock.Count() != 0 {
+		t.Errorf("resolveLock() without a previous lock = %+v, %v, want an empty lock", lock.Packages, errs)
+	}
+}
+
+func TestLoadDeclaredDetectsFormat(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		// A manifest and an environment file, each under the other's name
+		"ppm-env.yaml": "npm:\n  - typescript@5\n",
+		"ppm.yaml":     "version: 1\npackages:\n  npm:\n    - name: typescript\n      version: 5.4.5\n",
+		"env.json":     `{"version": 1, "packages": {"npm": [{"name": "typescript", "version": "5.4.5"}]}}`,
+	}
+	want := map[string]string{"ppm-env.yaml": "5", "ppm.yaml": "5.4.5", "env.json": "5.4.5"}
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		declared, _, err := loadDeclared(path)
+		if err != nil {
+			t.Errorf("loadDeclared(%s) error = %v", name, err)
+			continue
+		}
+		if specs := declared["npm"]; len(specs) != 1 || specs[0].Name != "typescript" || specs[0].Constraint != want[name] {
+			t.Errorf("loadDeclared(%s) = %+v, want typescript@%s", name, declared, want[name])
+		}
+	}
+}
+
+func TestCheckLockUnknownProvider(t *testing.T) {
+	mgr := manager.New()
+	mgr.RegisterManager(&stubManager{name: "npm"})
+
+	lock := lockfile.New()
+	lock.Add("npm", manager.ResolvedPackage{Name: "typescript", Version: "5.4.5"})
+	lock.Add("brew", manager.ResolvedPackage{Name: "git", Version: "2.45.1"})
+
+	if _, err := checkLock(context.Background(), mgr, lock); err == nil || !strings.Contains(err.Error(), "brew") {
+		t.Errorf("checkLock() error = %v, want one about brew", err)
+	}
+}
@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/RichestHumanAlive/ppm_cli/pkg/manager"
+	"github.com/RichestHumanAlive/ppm_cli/pkg/manager/npm"
+	"github.com/RichestHumanAlive/ppm_cli/pkg/manager/pip"
+	"github.com/RichestHumanAlive/ppm_cli/pkg/manager/scoop"
+)
+
+// newManager returns a Manager with every supported package manager registered
+func newManager(opts ...manager.Option) *manager.Manager {
+	mgr := manager.New(opts...)
+	mgr.RegisterManager(npm.New())
+	mgr.RegisterManager(pip.New(pipOptions()...))
+	mgr.RegisterManager(scoop.New())
+	return mgr
+}
+
+// pipOptions points pip searches at the index named by PPM_PYPI_INDEX_URL or,
+// failing that, pip's own PIP_INDEX_URL, and keeps the index listing in the
+// user's cache directory
+func pipOptions() []pip.Option {
+	var opts []pip.Option
+	if dir, err := os.UserCacheDir(); err == nil {
+		opts = append(opts, pip.WithCacheDir(filepath.Join(dir, "ppm")))
+	}
+	for _, env := range []string{"PPM_PYPI_INDEX_URL", "PIP_INDEX_URL"} {
+		if url := os.Getenv(env); url != "" {
+			return append(opts, pip.WithIndexURL(url))
+		}
+	}
+	return opts
+}
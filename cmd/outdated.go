@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/RichestHumanAlive/ppm_cli/pkg/manager"
+	"github.com/spf13/cobra"
+)
+
+// listOutdated gathers outdated packages from every available package manager
+// in pms. Failing managers are reported in the returned map.
+func listOutdated(ctx context.Context, pms []manager.PackageManager) ([]manager.OutdatedPackage, map[string]error) {
+	var (
+		mu       sync.Mutex
+		packages = make([]manager.OutdatedPackage, 0)
+	)
+
+	errs := forEachAvailable(ctx, pms, func(pm manager.PackageManager) error {
+		pkgs, err := pm.ListOutdated(ctx)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		packages = append(packages, pkgs...)
+		mu.Unlock()
+		return nil
+	})
+
+	sort.SliceStable(packages, func(i, j int) bool {
+		if packages[i].Provider != packages[j].Provider {
+			return packages[i].Provider < packages[j].Provider
+		}
+		return strings.ToLower(packages[i].Name) < strings.ToLower(packages[j].Name)
+	})
+
+	return packages, errs
+}
+
+func renderOutdatedTable(pkgs []manager.OutdatedPackage) string {
+	rows := make([][]string, 0, len(pkgs))
+	for _, pkg := range pkgs {
+		rows = append(rows, []string{pkg.Name, pkg.Provider, pkg.Current, pkg.Wanted, pkg.Latest})
+	}
+
+	return renderTable([]column{
+		{header: "Name", style: titleStyle},
+		{header: "Provider", style: providerStyle},
+		{header: "Current", style: errorStyle},
+		{header: "Wanted", style: versionStyle, priority: 1},
+		{header: "Latest", style: successStyle},
+	}, rows)
+}
+
+func NewOutdatedCmd() *cobra.Command {
+	var (
+		providers []string
+		asJSON    bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "outdated",
+		Short: "Show installed packages that have newer versions available",
+		Long: `Show installed packages that have newer versions available across all
+package managers, with their current, wanted and latest versions.
+
+The command exits with status 1 when any package is outdated, so it can be
+used to fail CI jobs on stale dependencies.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := getOutputFormat(cmd)
+			if err != nil {
+				return err
+			}
+			if asJSON {
+				format = outputJSON
+			}
+
+			mgr := newManager()
+
+			pms, err := selectManagers(mgr, providers)
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := operationContext(cmd)
+			defer cancel()
+
+			var (
+				pkgs []manager.OutdatedPackage
+				errs map[string]error
+			)
+			err = runWithStatus(ctx, format, "Checking for outdated packages...", func(ctx context.Context) error {
+				pkgs, errs = listOutdated(ctx, pms)
+				return ctx.Err()
+			})
+			if err != nil {
+				return err
+			}
+
+			warnProviderErrors("check", errs)
+
+			if format.structured() {
+				if err := writeStructured(os.Stdout, format, pkgs); err != nil {
+					return fmt.Errorf("failed to encode outdated packages: %v", err)
+				}
+			} else if len(pkgs) == 0 {
+				fmt.Println("\n✓ All packages are up to date")
+			} else {
+				fmt.Printf("\n%d outdated packages\n\n", len(pkgs))
+				fmt.Print(renderOutdatedTable(pkgs))
+			}
+
+			if len(pkgs) > 0 {
+				return fmt.Errorf("%d packages are outdated", len(pkgs))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringSliceVarP(&providers, "provider", "p", nil, "only check packages from these package managers (npm, pip, scoop)")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "print the outdated packages as JSON (same as --output json)")
+
+	return cmd
+}
@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// outputFormat is the value of the global --output flag
+type outputFormat string
+
+const (
+	outputTable outputFormat = "table"
+	outputJSON  outputFormat = "json"
+	outputYAML  outputFormat = "yaml"
+	outputCSV   outputFormat = "csv"
+)
+
+// getOutputFormat returns the output format selected with the global --output
+// flag. Commands run without the root command print tables.
+func getOutputFormat(cmd *cobra.Command) (outputFormat, error) {
+	value, err := cmd.Flags().GetString("output")
+	if err != nil {
+		return outputTable, nil
+	}
+
+	switch format := outputFormat(strings.ToLower(value)); format {
+	case outputTable, outputJSON, outputYAML, outputCSV:
+		return format, nil
+	}
+	return "", UsageError(fmt.Errorf("invalid output format %q (expected table, json, yaml or csv)", value))
+}
+
+// structured reports whether the format is meant for other programs, in which
+// case commands print nothing but the data to standard output
+func (f outputFormat) structured() bool {
+	return f != outputTable
+}
+
+// writeStructured encodes v, a slice of structs, in a machine-readable format.
+// Field names come from the structs' json tags.
+func writeStructured(w io.Writer, format outputFormat, v interface{}) error {
+	switch format {
+	case outputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false) // Keep version constraints such as "<4" readable
+		return enc.Encode(v)
+	case outputYAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+		return enc.Close()
+	case outputCSV:
+		return writeCSV(w, v)
+	default:
+		return fmt.Errorf("unsupported output format %q", format)
+	}
+}
+
+// writeCSV writes a slice of structs as CSV with a header row of json field
+// names. Embedded structs are flattened like encoding/json does, and list
+// fields are joined with ";".
+func writeCSV(w io.Writer, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice || rv.Type().Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("cannot write %T as CSV", v)
+	}
+
+	var (
+		header []string
+		fields [][]int // Index paths of the columns, through embedded structs
+	)
+	var collect func(typ reflect.Type, path []int)
+	collect = func(typ reflect.Type, path []int) {
+		for i := 0; i < typ.NumField(); i++ {
+			field := typ.Field(i)
+			index := append(append([]int{}, path...), i)
+			if field.Anonymous && field.Type.Kind() == reflect.Struct {
+				collect(field.Type, index)
+				continue
+			}
+			name := strings.Split(field.Tag.Get("json"), ",")[0]
+			if name == "" || name == "-" || !field.IsExported() {
+				continue
+			}
+			header = append(header, name)
+			fields = append(fields, index)
+		}
+	}
+	collect(rv.Type().Elem(), nil)
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for r := 0; r < rv.Len(); r++ {
+		record := make([]string, 0, len(fields))
+		for _, index := range fields {
+			record = append(record, csvCell(rv.Index(r).FieldByIndex(index)))
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func csvCell(v reflect.Value) string {
+	if s, ok := v.Interface().(fmt.Stringer); ok {
+		return s.String()
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 64)
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Slice:
+		items := make([]string, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			items = append(items, csvCell(v.Index(i)))
+		}
+		return strings.Join(items, ";")
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+// runWithStatus runs fn with runWithSpinner, or without a spinner but with
+// the same timeout when the command prints machine-readable output
+func runWithStatus(ctx context.Context, format outputFormat, message string, fn func(ctx context.Context) error) error {
+	if !format.structured() {
+		return runWithSpinner(ctx, message, fn)
+	}
+
+	ctx, cancel := withOperationTimeout(ctx)
+	defer cancel()
+	if err := fn(ctx); err != nil {
+		return interruptedError(ctx, err)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/RichestHumanAlive/ppm_cli/pkg/manager"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata/output")
+
+var outputFixtures = map[string]interface{}{
+	"packages": []manager.Package{
+		{
+			Name:        "typescript",
+			Version:     "5.4.5",
+			Description: "TypeScript is a language for application-scale JavaScript",
+			Author:      "Microsoft Corp.",
+			Provider:    "npm",
+			Score:       0.92,
+			Downloads:   52000000,
+			Homepage:    "https://www.typescriptlang.org/",
+			Repository:  "https://github.com/microsoft/TypeScript",
+			License:     "Apache-2.0",
+			Keywords:    []string{"TypeScript", "Microsoft", "compiler"},
+			Maintainers: []string{"typescript-bot", "weswigham"},
+			Updated:     "2024-04-16",
+		},
+		{
+			Name:        "black",
+			Version:     "24.4.2",
+			Description: `The uncompromising code formatter, "any color you like", as long as it's black`,
+			Provider:    "pip",
+			Source:      "pypi",
+			Scope:       "user",
+		},
+	},
+	"outdated": []manager.OutdatedPackage{
+		{Name: "eslint", Provider: "npm", Current: "8.56.0", Wanted: "8.57.0", Latest: "9.2.0"},
+		{Name: "git", Provider: "scoop", Current: "2.44.0", Wanted: "2.45.1", Latest: "2.45.1"},
+	},
+	"info": []manager.PackageInfo{
+		{
+			Package: manager.Package{
+				Name:        "requests",
+				Version:     "2.31.0",
+				Description: "Python HTTP for Humans.",
+				Author:      "Kenneth Reitz",
+				Provider:    "pip",
+				Homepage:    "https://requests.readthedocs.io",
+				License:     "Apache-2.0",
+			},
+			Releases: []manager.Release{
+				{Version: "2.31.0", Date: "2023-05-22"},
+				{Version: "2.30.0"},
+			},
+			Dependencies: []string{"charset-normalizer<4,>=2", "idna<4,>=2.5"},
+			Installed:    "2.30.0",
+		},
+	},
+}
+
+func TestWriteStructuredGolden(t *testing.T) {
+	for name, v := range outputFixtures {
+		for _, format := range []outputFormat{outputJSON, outputYAML, outputCSV} {
+			t.Run(name+"."+string(format), func(t *testing.T) {
+				var buf bytes.Buffer
+				if err := writeStructured(&buf, format, v); err != nil {
+					t.Fatalf("writeStructured() error = %v", err)
+				}
+
+				golden := filepath.Join("testdata", "output", name+"."+string(format))
+				if *update {
+					if err := os.WriteFile(golden, buf.Bytes(), 0o644); err != nil {
+						t.Fatal(err)
+					}
+				}
+				want, err := os.ReadFile(golden)
+				if err != nil {
+					t.Fatalf("%v (run the tests with -update to create it)", err)
+				}
+				if got := buf.String(); got != string(want) {
+					t.Errorf("output differs from %s:\n%s\nwant:\n%s", golden, got, want)
+				}
+			})
+		}
+	}
+}
+
+func TestWriteCSVRejectsNonSlices(t *testing.T) {
+	for _, v := range []interface{}{manager.Package{Name: "lodash"}, []string{"lodash"}} {
+		if err := writeCSV(&bytes.Buffer{}, v); err == nil {
+			t.Errorf("writeCSV(%T) succeeded, want an error", v)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/RichestHumanAlive/ppm_cli/pkg/manager"
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// task is one package manager operation run by runTasks
+type task struct {
+	label string                 // Shown on the task's progress line
+	pm    manager.PackageManager // Package manager the operation runs with
+	run   func(ctx context.Context) error
+}
+
+// taskState is the progress of one task
+type taskState int
+
+const (
+	taskQueued taskState = iota
+	taskRunning
+	taskDone
+	taskFailed
+)
+
+// taskStartedMsg and taskFinishedMsg report task progress to progressModel
+type taskStartedMsg struct{ index int }
+
+type taskFinishedMsg struct {
+	index int
+	err   error
+}
+
+// progressModel is a bubbletea model that shows one line per task
+type progressModel struct {
+	spinner  spinner.Model
+	tasks    []task
+	states   []taskState
+	errs     []error
+	started  []time.Time
+	elapsed  []time.Duration
+	cancel   context.CancelFunc
+	quitting bool
+}
+
+func (m progressModel) Init() tea.Cmd {
+	return m.spinner.Tick
+}
+
+func (m progressModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" {
+			// The terminal is in raw mode, so Ctrl-C arrives as a key
+			// press rather than SIGINT; stop the whole command
+			m.cancel()
+			m.quitting = true
+			return m, tea.Quit
+		}
+	case taskStartedMsg:
+		m.states[msg.index] = taskRunning
+		m.started[msg.index] = time.Now()
+	case taskFinishedMsg:
+		m.states[msg.index] = taskDone
+		if msg.err != nil {
+			m.states[msg.index] = taskFailed
+			m.errs[msg.index] = msg.err
+		}
+		m.elapsed[msg.index] = time.Since(m.started[msg.index]).Round(100 * time.Millisecond)
+	default:
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+func (m progressModel) View() string {
+	var sb strings.Builder
+	sb.WriteString("\n")
+	for i, t := range m.tasks {
+		switch m.states[i] {
+		case taskQueued:
+			fmt.Fprintf(&sb, " %s %s %s\n", mutedStyle.Render("·"), t.label, mutedStyle.Render("queued"))
+		case taskRunning:
+			fmt.Fprintf(&sb, " %s%s\n", m.spinner.View(), t.label)
+		case taskDone:
+			fmt.Fprintf(&sb, " %s %s %s\n", successStyle.Render("✓"), t.label, mutedStyle.Render(m.elapsed[i].String()))
+		case taskFailed:
+			fmt.Fprintf(&sb, " %s %s %s\n", errorStyle.Render("✗"), t.label, errorStyle.Render(firstLine(m.errs[i].Error())))
+		}
+	}
+	if m.quitting {
+		sb.WriteString(mutedStyle.Render(" Interrupted") + "\n")
+	}
+	return sb.String()
+}
+
+// runTasks runs tasks concurrently, at most jobs at a time and no more per
+// package manager than it allows, e.g. one at a time for pip. Progress is shown with one line per task, or printed as tasks
+// finish when the output is not a terminal. Each task is given the --timeout
+// carried by ctx from when it starts. The returned errors are indexed like
+// tasks.
+func runTasks(ctx context.Context, tasks []task, jobs int) []error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	if jobs > len(tasks) {
+		jobs = len(tasks)
+	}
+	slots := make(chan struct{}, jobs)
+
+	providerSlots := make(map[string]chan struct{})
+	for _, t := range tasks {
+		if n := manager.MaxConcurrency(t.pm); n > 0 && providerSlots[t.pm.GetName()] == nil {
+			providerSlots[t.pm.GetName()] = make(chan struct{}, n)
+		}
+	}
+
+	var report func(msg tea.Msg)
+	var p *tea.Program
+	done := make(chan struct{})
+	if isInteractive() {
+		s := spinner.New()
+		s.Spinner = spinner.Dot
+
+		n := len(tasks)
+		p = tea.NewProgram(progressModel{
+			spinner: s,
+			tasks:   tasks,
+			states:  make([]taskState, n),
+			errs:    make([]error, n),
+			started: make([]time.Time, n),
+			elapsed: make([]time.Duration, n),
+			cancel:  func() { interrupt(ctx); cancel() },
+		}, tea.WithContext(ctx))
+		report = p.Send
+
+		go func() {
+			defer close(done)
+			if _, err := p.Run(); err != nil && !errors.Is(err, tea.ErrProgramKilled) {
+				fmt.Printf("Error starting progress display: %v\n", err)
+			}
+		}()
+	} else {
+		var mu sync.Mutex
+		report = func(msg tea.Msg) {
+			if msg, ok := msg.(taskFinishedMsg); ok {
+				mu.Lock()
+				defer mu.Unlock()
+				if msg.err != nil {
+					fmt.Printf("✗ %s: %s\n", tasks[msg.index].label, firstLine(msg.err.Error()))
+				} else {
+					fmt.Printf("✓ %s\n", tasks[msg.index].label)
+				}
+			}
+		}
+		close(done)
+	}
+
+	errs := make([]error, len(tasks))
+	var wg sync.WaitGroup
+	for i, t := range tasks {
+		wg.Add(1)
+		go func(i int, t task) {
+			defer wg.Done()
+
+			// Take the package manager's slot first so that tasks waiting
+			// on a serialized package manager do not hold a job slot
+			if ps := providerSlots[t.pm.GetName()]; ps != nil {
+				select {
+				case ps <- struct{}{}:
+					defer func() { <-ps }()
+				case <-ctx.Done():
+					errs[i] = interruptedError(ctx, ctx.Err())
+					report(taskFinishedMsg{index: i, err: errs[i]})
+					return
+				}
+			}
+			select {
+			case slots <- struct{}{}:
+				defer func() { <-slots }()
+			case <-ctx.Done():
+				errs[i] = interruptedError(ctx, ctx.Err())
+				report(taskFinishedMsg{index: i, err: errs[i]})
+				return
+			}
+
+			report(taskStartedMsg{index: i})
+			taskCtx, cancelTask := withOperationTimeout(ctx)
+			if err := t.run(taskCtx); err != nil {
+				errs[i] = interruptedError(taskCtx, err)
+			}
+			cancelTask()
+			report(taskFinishedMsg{index: i, err: errs[i]})
+		}(i, t)
+	}
+	wg.Wait()
+
+	if p != nil {
+		p.Quit()
+	}
+	<-done
+	return errs
+}
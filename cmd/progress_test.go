@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/RichestHumanAlive/ppm_cli/pkg/manager"
+)
+
+// limitedManager is a stubManager that runs at most max operations at once
+type limitedManager struct {
+	stubManager
+	max int
+}
+
+func (l *limitedManager) MaxConcurrency() int { return l.max }
+
+// concurrencyProbe records how many tasks run at the same time, overall and
+// per package manager
+type concurrencyProbe struct {
+	mu               sync.Mutex
+	running, peak    map[string]int
+	total, peakTotal int
+}
+
+func newConcurrencyProbe() *concurrencyProbe {
+	return &concurrencyProbe{running: make(map[string]int), peak: make(map[string]int)}
+}
+
+// task returns a task of pm that holds its slot for a while
+func (p *concurrencyProbe) task(pm manager.PackageManager, name string) task {
+	provider := pm.GetName()
+	run := func(ctx context.Context) error {
+		p.mu.Lock()
+		p.running[provider]++
+		p.total++
+		p.peak[provider] = max(p.peak[provider], p.running[provider])
+		p.peakTotal = max(p.peakTotal, p.total)
+		p.mu.Unlock()
+
+		time.Sleep(50 * time.Millisecond)
+
+		p.mu.Lock()
+		p.running[provider]--
+		p.total--
+		p.mu.Unlock()
+		return nil
+	}
+	return task{label: name, pm: pm, run: run}
+}
+
+func TestRunTasksConcurrency(t *testing.T) {
+	tests := []struct {
+		jobs      int
+		npm, pip  int // Tasks of each package manager; pip runs one at a time
+		peakTotal int
+		peakNPM   int
+	}{
+		{jobs: 4, npm: 6, peakTotal: 4, peakNPM: 4},
+		{jobs: 1, npm: 3, peakTotal: 1, peakNPM: 1},
+		{jobs: 10, npm: 3, peakTotal: 3, peakNPM: 3},
+		{jobs: 4, npm: 3, pip: 3, peakTotal: 4, peakNPM: 3},
+		{jobs: 2, pip: 4, peakTotal: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("jobs=%d,npm=%d,pip=%d", tt.jobs, tt.npm, tt.pip), func(t *testing.T) {
+			probe := newConcurrencyProbe()
+			npm := &stubManager{name: "npm"}
+			pip := &limitedManager{stubManager: stubManager{name: "pip"}, max: 1}
+
+			var tasks []task
+			for i := 0; i < tt.npm; i++ {
+				tasks = append(tasks, probe.task(npm, fmt.Sprintf("npm%d", i)))
+			}
+			for i := 0; i < tt.pip; i++ {
+				tasks = append(tasks, probe.task(pip, fmt.Sprintf("pip%d", i)))
+			}
+
+			for i, err := range runTasks(stubCommandContext(t), tasks, tt.jobs) {
+				if err != nil {
+					t.Errorf("task %d failed: %v", i, err)
+				}
+			}
+			if probe.peakTotal != tt.peakTotal {
+				t.Errorf("peak concurrency = %d, want %d", probe.peakTotal, tt.peakTotal)
+			}
+			if probe.peak["npm"] != tt.peakNPM {
+				t.Errorf("peak npm concurrency = %d, want %d", probe.peak["npm"], tt.peakNPM)
+			}
+			if tt.pip > 0 && probe.peak["pip"] != 1 {
+				t.Errorf("peak pip concurrency = %d, want 1", probe.peak["pip"])
+			}
+		})
+	}
+}
+
+func TestCheckJobs(t *testing.T) {
+	for _, jobs := range []int{0, -1} {
+		if err := checkJobs(jobs); ExitCode(err) != ExitUsage {
+			t.Errorf("checkJobs(%d) = %v, want a usage error", jobs, err)
+		}
+	}
+	if err := checkJobs(1); err != nil {
+		t.Errorf("checkJobs(1) = %v, want nil", err)
+	}
+}
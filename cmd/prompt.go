@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// promptChoice lists options with 1-based numbers and reads the user's pick.
+// It returns the index of the chosen option.
+func promptChoice(question string, options []string) (int, error) {
+	fmt.Println(question)
+	for i, option := range options {
+		fmt.Printf("  %d) %s\n", i+1, option)
+	}
+	fmt.Printf("Enter a number (or 'q' to quit): ")
+
+	var input string
+	fmt.Scanln(&input)
+
+	if input == "q" {
+		return -1, fmt.Errorf("aborted")
+	}
+
+	var selection int
+	if _, err := fmt.Sscanf(input, "%d", &selection); err != nil || selection < 1 || selection > len(options) {
+		return -1, fmt.Errorf("invalid selection")
+	}
+
+	return selection - 1, nil
+}
+
+// confirm asks a yes/no question and reports whether the user answered yes
+func confirm(question string) bool {
+	fmt.Printf("%s [y/N] ", question)
+
+	var answer string
+	fmt.Scanln(&answer)
+
+	return strings.ToLower(answer) == "y"
+}
+
+// confirmChanges asks before changing n packages. --yes answers for the user;
+// when PPM cannot ask, the changes are refused.
+func confirmChanges(n int) error {
+	if assumeYes {
+		return nil
+	}
+	if !isInteractive() {
+		return inputRequiredError(fmt.Errorf("refusing to change %d packages without confirmation; re-run with --yes", n))
+	}
+	if !confirm(fmt.Sprintf("\nApply %d changes?", n)) {
+		return fmt.Errorf("aborted")
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/RichestHumanAlive/ppm_cli/pkg/manager"
+	"github.com/spf13/cobra"
+)
+
+// runRemove removes a package from the package manager that has it
+// installed, asking which one when several do
+func runRemove(ctx context.Context, mgr *manager.Manager, spec manager.PackageSpec) error {
+	pm, err := resolveInstalled(ctx, mgr, spec, "Remove it from")
+	if err != nil {
+		return err
+	}
+
+	err = runWithSpinner(ctx, fmt.Sprintf("Removing %s with %s...", spec.Name, pm.GetName()), func(ctx context.Context) error {
+		return pm.Remove(ctx, spec.Name)
+	})
+	if ctx.Err() != nil || isInterrupted(err) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("removal failed: %v", err)
+	}
+
+	fmt.Printf("\n✓ Successfully removed %s from %s\n", titleStyle.Render(spec.Name), providerStyle.Render(pm.GetName()))
+	return nil
+}
+
+func NewRemoveCmd() *cobra.Command {
+	var provider string
+
+	cmd := &cobra.Command{
+		Use:   "remove [package]",
+		Short: "Remove a package from the package manager that installed it",
+		Long: `Remove a package from the package manager that installed it.
+
+PPM first checks which package managers have the package installed. When
+more than one does, you are asked which one to remove it from, unless the
+package manager is given with a prefix (e.g. "pip:requests") or --provider.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			spec, err := parseSpecArg(args[0], false)
+			if err != nil {
+				return err
+			}
+			if provider != "" {
+				if spec.Provider != "" && spec.Provider != provider {
+					return fmt.Errorf("conflicting package managers: %s prefix and --provider %s", spec.Provider, provider)
+				}
+				spec.Provider = provider
+			}
+
+			ctx, cancel := operationContext(cmd)
+			defer cancel()
+
+			return runRemove(ctx, newManager(), spec)
+		},
+	}
+
+	cmd.Flags().StringVarP(&provider, "provider", "p", "", "package manager to remove the package from (npm, pip, scoop)")
+
+	return cmd
+}
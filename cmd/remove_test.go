@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/RichestHumanAlive/ppm_cli/pkg/manager"
+)
+
+func TestRunRemove(t *testing.T) {
+	npm := &stubManager{name: "npm", installed: []string{"prettier"}}
+	pip := &stubManager{name: "pip", installed: []string{"black"}}
+
+	if err := runRemove(stubCommandContext(t), stubManagers(npm, pip), manager.PackageSpec{Name: "black"}); err != nil {
+		t.Fatalf("runRemove(black) = %v", err)
+	}
+	if len(npm.changed) != 0 || len(pip.changed) != 1 || pip.changed[0] != "black" {
+		t.Errorf("removed %v from npm and %v from pip, want black from pip", npm.changed, pip.changed)
+	}
+
+	err := runRemove(stubCommandContext(t), stubManagers(npm, pip), manager.PackageSpec{Name: "left-pad"})
+	if err == nil || ExitCode(err) != ExitFailure {
+		t.Errorf("runRemove(left-pad) = %v, want a failure", err)
+	}
+}
+
+func TestRunRemoveFailure(t *testing.T) {
+	pip := &stubManager{name: "pip", installed: []string{"black"}, change: failing}
+
+	err := runRemove(stubCommandContext(t), stubManagers(pip), manager.PackageSpec{Name: "black"})
+	if err == nil || !strings.Contains(err.Error(), "removal failed") || ExitCode(err) != ExitFailure {
+		t.Errorf("runRemove() = %v, want removal failed", err)
+	}
+}
+
+func TestRunRemoveAmbiguous(t *testing.T) {
+	npm := &stubManager{name: "npm", installed: []string{"prettier"}}
+	pip := &stubManager{name: "pip", installed: []string{"prettier"}}
+
+	err := runRemove(stubCommandContext(t), stubManagers(npm, pip), manager.PackageSpec{Name: "prettier"})
+	if ExitCode(err) != ExitInputRequired {
+		t.Errorf("runRemove() = %v, want an input required error", err)
+	}
+	if len(npm.changed) != 0 || len(pip.changed) != 0 {
+		t.Errorf("removed %v from npm and %v from pip, want nothing", npm.changed, pip.changed)
+	}
+
+	// A prefix picks the package manager
+	err = runRemove(stubCommandContext(t), stubManagers(npm, pip), manager.PackageSpec{Provider: "npm", Name: "prettier"})
+	if err != nil || len(npm.changed) != 1 || len(pip.changed) != 0 {
+		t.Errorf("runRemove(npm:prettier) = %v, removed %v from npm and %v from pip", err, npm.changed, pip.changed)
+	}
+}
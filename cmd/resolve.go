@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/RichestHumanAlive/ppm_cli/pkg/manager"
+)
+
+// parseSpecArg parses a command argument such as "npm:lodash". Commands that
+// act on installed packages pass allowConstraint=false to reject versions.
+func parseSpecArg(arg string, allowConstraint bool) (manager.PackageSpec, error) {
+	spec, err := manager.ParseSpec(arg)
+	if err != nil {
+		return spec, err
+	}
+	if !allowConstraint && (spec.Constraint != "" || len(spec.Extras) > 0) {
+		return spec, fmt.Errorf("%s: a version constraint is not supported here, use the bare package name", arg)
+	}
+	return spec, nil
+}
+
+// providerForSpec returns the package manager named by the spec's provider
+// prefix, making sure it is registered and available on this system
+func providerForSpec(ctx context.Context, mgr *manager.Manager, spec manager.PackageSpec) (manager.PackageManager, error) {
+	pm, ok := mgr.GetManager(spec.Provider)
+	if !ok {
+		return nil, fmt.Errorf("unknown package manager: %s", spec.Provider)
+	}
+	if !pm.IsAvailable(ctx) {
+		return nil, fmt.Errorf("package manager %s is not available", spec.Provider)
+	}
+	return pm, nil
+}
+
+// chooseProvider picks one of several package managers that match name. It
+// asks the user when there is a choice to make, and fails with a hint about
+// provider prefixes when PPM cannot prompt.
+func chooseProvider(name, question string, candidates []manager.PackageManager, labels []string) (manager.PackageManager, error) {
+	if len(candidates) == 1 {
+		return candidates[0], nil
+	}
+
+	names := make([]string, len(candidates))
+	for i, pm := range candidates {
+		names[i] = pm.GetName()
+	}
+
+	if !isInteractive() {
+		return nil, inputRequiredError(fmt.Errorf("%s is ambiguous: it matches %s; prefix it with a package manager, e.g. %s:%s",
+			name, strings.Join(names, ", "), names[0], name))
+	}
+
+	if labels == nil {
+		labels = names
+	}
+	choice, err := promptChoice(question, labels)
+	if err != nil {
+		return nil, err
+	}
+	return candidates[choice], nil
+}
+
+// installedProviders returns the available package managers that have pkg installed
+func installedProviders(ctx context.Context, mgr *manager.Manager, pkg string) []manager.PackageManager {
+	providers := make([]manager.PackageManager, 0)
+	for _, pm := range mgr.GetManagers() {
+		if pm.IsAvailable(ctx) && pm.IsInstalled(ctx, pkg) {
+			providers = append(providers, pm)
+		}
+	}
+	return providers
+}
+
+// resolveInstalled picks the package manager that has the spec's package
+// installed, honoring a provider prefix. action describes the operation for
+// prompts, e.g. "Remove it from".
+func resolveInstalled(ctx context.Context, mgr *manager.Manager, spec manager.PackageSpec, action string) (manager.PackageManager, error) {
+	if spec.Provider != "" {
+		pm, err := providerForSpec(ctx, mgr, spec)
+		if err != nil {
+			return nil, err
+		}
+		if !pm.IsInstalled(ctx, spec.Name) {
+			return nil, fmt.Errorf("%s is not installed with %s", spec.Name, spec.Provider)
+		}
+		return pm, nil
+	}
+
+	var providers []manager.PackageManager
+	err := runWithSpinner(ctx, fmt.Sprintf("Looking for installed copies of '%s'...", spec.Name), func(ctx context.Context) error {
+		providers = installedProviders(ctx, mgr, spec.Name)
+		return ctx.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(providers) == 0 {
+		return nil, fmt.Errorf("%s is not installed with any available package manager", spec.Name)
+	}
+
+	question := fmt.Sprintf("\n%s is installed with several package managers. %s:", titleStyle.Render(spec.Name), action)
+	return chooseProvider(spec.Name, question, providers, nil)
+}
+
+// resolvePublished picks the package manager to install the spec's package
+// from, honoring a provider prefix. Without one, every available package
+// manager is asked whether it publishes a package with exactly that name.
+func resolvePublished(ctx context.Context, mgr *manager.Manager, spec manager.PackageSpec) (manager.PackageManager, error) {
+	if spec.Provider != "" {
+		return providerForSpec(ctx, mgr, spec)
+	}
+
+	var found []manager.Package
+	err := runWithSpinner(ctx, fmt.Sprintf("Looking up '%s' across package managers...", spec.Name), func(ctx context.Context) error {
+		var err error
+		found, err = mgr.FindExact(ctx, spec.Name)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not look up %s: %v", spec.Name, err)
+	}
+
+	if len(found) == 0 {
+		return nil, fmt.Errorf("no package named %s found with any available package manager", spec.Name)
+	}
+
+	candidates := make([]manager.PackageManager, 0, len(found))
+	labels := make([]string, 0, len(found))
+	for _, pkg := range found {
+		pm, ok := mgr.GetManager(pkg.Provider)
+		if !ok {
+			continue
+		}
+		candidates = append(candidates, pm)
+
+		label := fmt.Sprintf("%-6s %s %s", pkg.Provider, pkg.Name, pkg.Version)
+		if pkg.Description != "" {
+			label += " - " + pkg.Description
+		}
+		labels = append(labels, label)
+	}
+
+	question := fmt.Sprintf("\n%s is published by several package managers. Install it from:", titleStyle.Render(spec.Name))
+	return chooseProvider(spec.Name, question, candidates, labels)
+}
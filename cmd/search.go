@@ -1,17 +1,14 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
-	"sort"
+	"os"
 	"strings"
-	"unicode/utf8"
+	"time"
 
 	"github.com/RichestHumanAlive/ppm_cli/pkg/manager"
-	"github.com/RichestHumanAlive/ppm_cli/pkg/manager/npm"
-	"github.com/RichestHumanAlive/ppm_cli/pkg/manager/pip"
-	"github.com/RichestHumanAlive/ppm_cli/pkg/manager/scoop"
-	"github.com/charmbracelet/bubbles/spinner"
-	tea "github.com/charmbracelet/bubbletea"
+	"github.com/RichestHumanAlive/ppm_cli/pkg/ranking"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/spf13/cobra"
 )
@@ -19,364 +16,292 @@ import (
 var (
 	// Styles
 	titleStyle = lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#FF69B4")).
-		Bold(true)
+			Foreground(lipgloss.Color("#FF69B4")).
+			Bold(true)
 
 	providerStyle = lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#00FF00")).
-		Italic(true)
+			Foreground(lipgloss.Color("#00FF00")).
+			Italic(true)
 
 	versionStyle = lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#87CEEB"))
+			Foreground(lipgloss.Color("#87CEEB"))
 
 	descStyle = lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#FFFFFF"))
+			Foreground(lipgloss.Color("#FFFFFF"))
 
 	headerStyle = lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#FFA500")).
-		Bold(true)
+			Foreground(lipgloss.Color("#FFA500")).
+			Bold(true)
 
 	cellStyle = lipgloss.NewStyle().
-		PaddingLeft(1).
-		PaddingRight(1)
-)
-
-type searchModel struct {
-	spinner  spinner.Model
-	quitting bool
-	query    string
-}
-
-func (m searchModel) Init() tea.Cmd {
-	return m.spinner.Tick
-}
-
-func (m searchModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	switch msg := msg.(type) {
-	case tea.KeyMsg:
-		if msg.String() == "q" {
-			m.quitting = true
-			return m, tea.Quit
-		}
-	default:
-		var cmd tea.Cmd
-		m.spinner, cmd = m.spinner.Update(msg)
-		return m, cmd
-	}
-	return m, nil
-}
-
-func (m searchModel) View() string {
-	if m.quitting {
-		return ""
-	}
-	return fmt.Sprintf("\n %s Searching for '%s' across package managers...\n", m.spinner.View(), m.query)
-}
-
-func clearScreen() {
-	fmt.Print("\033[H\033[2J")
-}
-
-func renderTable(results []manager.Package) string {
-	if len(results) == 0 {
-		return "No packages found"
-	}
+			PaddingLeft(1).
+			PaddingRight(1)
 
-	// Define columns
-	headers := []string{"ID", "Name", "Version", "Provider", "Description"}
-	widths := make([]int, len(headers))
+	successStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#00FF00"))
 
-	// Calculate maximum widths for each column
-	for i, header := range headers {
-		widths[i] = utf8.RuneCountInString(header)
-	}
+	errorStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#FF0000"))
 
-	for _, pkg := range results {
-		name := pkg.Name
-		if len(name) > widths[1] {
-			widths[1] = len(name)
-		}
-		if len(pkg.Version) > widths[2] {
-			widths[2] = len(pkg.Version)
-		}
-		if len(pkg.Provider) > widths[3] {
-			widths[3] = len(pkg.Provider)
-		}
-		desc := pkg.Description
-		if len(desc) > 50 { // Truncate long descriptions
-			desc = desc[:47] + "..."
-		}
-		if len(desc) > widths[4] {
-			widths[4] = len(desc)
-		}
-	}
-
-	// Build the table
-	var sb strings.Builder
-
-	// Write headers
-	sb.WriteString("┌")
-	for i, w := range widths {
-		sb.WriteString(strings.Repeat("─", w+2))
-		if i < len(widths)-1 {
-			sb.WriteString("┬")
-		}
-	}
-	sb.WriteString("┐\n")
-
-	// Write header row
-	sb.WriteString("│")
-	for i, header := range headers {
-		cell := headerStyle.Render(fmt.Sprintf("%-*s", widths[i], header))
-		sb.WriteString(cellStyle.Render(cell))
-		sb.WriteString("│")
-	}
-	sb.WriteString("\n")
-
-	// Write separator
-	sb.WriteString("├")
-	for i, w := range widths {
-		sb.WriteString(strings.Repeat("─", w+2))
-		if i < len(widths)-1 {
-			sb.WriteString("┼")
-		}
-	}
-	sb.WriteString("┤\n")
-
-	// Write data rows
-	for i, pkg := range results {
-		desc := pkg.Description
-		if len(desc) > 50 {
-			desc = desc[:47] + "..."
-		}
-
-		sb.WriteString("│")
-		cells := []string{
-			fmt.Sprintf("%d", i+1),
-			titleStyle.Render(pkg.Name),
-			versionStyle.Render(pkg.Version),
-			providerStyle.Render(pkg.Provider),
-			descStyle.Render(desc),
-		}
-
-		for j, cell := range cells {
-			paddedCell := fmt.Sprintf("%-*s", widths[j], lipgloss.NewStyle().Render(cell))
-			sb.WriteString(cellStyle.Render(paddedCell))
-			sb.WriteString("│")
-		}
-		sb.WriteString("\n")
-	}
-
-	// Write bottom border
-	sb.WriteString("└")
-	for i, w := range widths {
-		sb.WriteString(strings.Repeat("─", w+2))
-		if i < len(widths)-1 {
-			sb.WriteString("┴")
-		}
-	}
-	sb.WriteString("┘\n")
-
-	return sb.String()
-}
+	mutedStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#808080"))
+)
 
 func NewSearchCmd() *cobra.Command {
+	var (
+		providerTimeout time.Duration
+		jobs            int
+		columnNames     []string
+		weightsValue    string
+		preferNames     []string
+	)
+
 	cmd := &cobra.Command{
 		Use:   "search [query]",
 		Short: "Search for packages across all package managers",
-		Args:  cobra.ExactArgs(1),
+		Long: `Search for packages across all package managers.
+
+In a terminal, results are shown in a browser as each package manager
+responds:
+
+  ↑/↓, j/k, pgup/pgdown  move through the results
+  /                      filter the results by name or description
+  1-9                    show or hide the results of a package manager
+  space                  select a package (one per project)
+  enter                  install the selected packages, or the current one
+  q, esc                 quit without installing
+
+When the output is not a terminal, or with --output, the results are printed
+instead, best match first.
+
+Results are ranked by one score that blends, by weight:
+
+  relevance   the package manager's own score, relative to its other results
+  exact       the name is the query
+  similarity  how closely the name matches the query
+  popularity  downloads
+  recency     how recently the latest version was released
+  available   the package manager is installed
+
+Signals a package manager does not report are left out. Change the weights
+with --weights or $PPM_SEARCH_WEIGHTS, e.g. "exact=4,popularity=0.5".
+
+Packages of one project that several package managers publish, recognized by
+a shared repository or homepage, are grouped: the package of the preferred
+package manager comes first and the others are listed below it. Set the order
+of preference with --prefer or $PPM_PREFER, e.g. "scoop,npm,pip"; otherwise
+the best ranked package comes first.`,
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			query := args[0]
 
-			// Initialize spinner
-			s := spinner.New()
-			s.Spinner = spinner.Dot
-			s.Style = s.Style.Foreground(s.Style.GetForeground())
+			format, err := getOutputFormat(cmd)
+			if err != nil {
+				return err
+			}
+			cols, err := selectPackageColumns(columnNames)
+			if err != nil {
+				return err
+			}
+			weights, err := searchWeights(cmd, weightsValue)
+			if err != nil {
+				return err
+			}
+			if err := checkJobs(jobs); err != nil {
+				return err
+			}
 
 			// Initialize manager
-			mgr := manager.New()
-			mgr.RegisterManager(npm.New())
-			mgr.RegisterManager(pip.New())
-			mgr.RegisterManager(scoop.New())
-
-			// Create channels for each package manager's results
-			npmChan := make(chan []manager.Package)
-			pipChan := make(chan []manager.Package)
-			scoopChan := make(chan []manager.Package)
-
-			// Create and start spinner
-			m := searchModel{spinner: s, query: query}
-			p := tea.NewProgram(m)
-
-			// Start searches in parallel
-			go func() {
-				if npm := npm.New(); npm.IsAvailable() {
-					results, err := npm.Search(query)
-					if err != nil {
-						npmChan <- nil
-					} else {
-						npmChan <- results
-					}
-				} else {
-					npmChan <- nil
-				}
-			}()
-
-			go func() {
-				if pip := pip.New(); pip.IsAvailable() {
-					results, err := pip.Search(query)
-					if err != nil {
-						pipChan <- nil
-					} else {
-						pipChan <- results
-					}
-				} else {
-					pipChan <- nil
+			mgr := newManager(manager.WithSearchTimeout(providerTimeout))
+			priority, err := searchPriority(cmd, mgr, preferNames)
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := operationContext(cmd)
+			defer cancel()
+
+			// The browser is only for people at a terminal who did not ask
+			// for a particular output format
+			if !isInteractive() || cmd.Flags().Changed("output") {
+				results, failed, err := collectSearch(ctx, mgr, query, weights)
+				if err != nil {
+					return err
 				}
-			}()
-
-			go func() {
-				if scoop := scoop.New(); scoop.IsAvailable() {
-					results, err := scoop.Search(query)
-					if err != nil {
-						scoopChan <- nil
-					} else {
-						scoopChan <- results
+				if failed != nil {
+					if len(results) == 0 {
+						return fmt.Errorf("search failed: %v", failed)
 					}
-				} else {
-					scoopChan <- nil
+					warnProviderErrors("search", failed.ByProvider())
 				}
-			}()
 
-			// Start spinner
-			go func() {
-				if err := p.Start(); err != nil {
-					fmt.Printf("Error starting spinner: %v\n", err)
+				groups := ranking.GroupProjects(results, priority)
+				if format.structured() {
+					return writeStructured(os.Stdout, format, searchResults(groups))
+				}
+				if len(results) == 0 {
+					fmt.Printf("No packages found matching '%s'\n", query)
+					return nil
 				}
-			}()
-
-			// Collect results
-			var allResults []manager.Package
-			
-			// Wait for all package managers to respond
-			npmResults := <-npmChan
-			pipResults := <-pipChan
-			scoopResults := <-scoopChan
-
-			// Stop spinner and clear its output
-			p.Quit()
-			clearScreen()
-
-			// Combine results
-			if npmResults != nil {
-				allResults = append(allResults, npmResults...)
+				fmt.Printf("\nFound %d packages matching '%s'\n\n", len(results), query)
+				fmt.Print(renderSearchTable(groups, cols))
+				return nil
 			}
-			if pipResults != nil {
-				allResults = append(allResults, pipResults...)
+
+			chosen, failed, err := browseSearch(ctx, mgr, query, weights, priority)
+			if err != nil {
+				return err
 			}
-			if scoopResults != nil {
-				allResults = append(allResults, scoopResults...)
+			// Warn once the browser has left the alternate screen
+			if failed != nil {
+				warnProviderErrors("search", failed.ByProvider())
 			}
-
-			if len(allResults) == 0 {
-				fmt.Printf("No packages found matching '%s'\n", query)
+			if len(chosen) == 0 {
 				return nil
 			}
 
-			// Sort results by score
-			sort.Slice(allResults, func(i, j int) bool {
-				return allResults[i].Score > allResults[j].Score
-			})
-
-			// Show results table
-			fmt.Printf("\nFound %d packages matching '%s'\n\n", len(allResults), query)
-			fmt.Print(renderTable(allResults))
-			fmt.Println("\nEnter package number to install (or 'q' to quit): ")
-
-			// Get user selection
-			var input string
-			fmt.Scanln(&input)
-
-			if input == "q" {
-				return nil
+			tasks := make([]task, 0, len(chosen))
+			for _, pkg := range chosen {
+				pkg := pkg
+				pm, ok := mgr.GetManager(pkg.Provider)
+				if !ok {
+					return fmt.Errorf("unknown package manager: %s", pkg.Provider)
+				}
+				tasks = append(tasks, task{
+					label: fmt.Sprintf("%s with %s", pkg.Name, pkg.Provider),
+					pm:    pm,
+					run: func(ctx context.Context) error {
+						return pm.Install(ctx, manager.PackageSpec{Name: pkg.Name})
+					},
+				})
 			}
 
-			var selection int
-			_, err := fmt.Sscanf(input, "%d", &selection)
-			if err != nil || selection < 1 || selection > len(allResults) {
-				return fmt.Errorf("invalid selection")
+			errs := runTasks(ctx, tasks, jobs)
+			if ctx.Err() != nil {
+				return interruptedError(ctx, ctx.Err())
 			}
 
-			selectedPkg := allResults[selection-1]
-
-			// Show package details
-			fmt.Println("\nPackage Details:")
-			fmt.Printf("Name: %s\n", titleStyle.Render(selectedPkg.Name))
-			fmt.Printf("Provider: %s\n", providerStyle.Render(selectedPkg.Provider))
-			fmt.Printf("Version: %s\n", versionStyle.Render(selectedPkg.Version))
-			fmt.Printf("Description: %s\n", descStyle.Render(selectedPkg.Description))
-			if selectedPkg.Author != "" {
-				fmt.Printf("Author: %s\n", selectedPkg.Author)
+			failures := 0
+			for i, err := range errs {
+				if err != nil {
+					if len(tasks) == 1 {
+						return fmt.Errorf("installation failed: %v", err)
+					}
+					fmt.Fprintf(os.Stderr, "\nerror: installing %s failed: %v\n", tasks[i].label, err)
+					failures++
+				}
 			}
-			if selectedPkg.Homepage != "" {
-				fmt.Printf("Homepage: %s\n", selectedPkg.Homepage)
+			if failures > 0 {
+				return fmt.Errorf("%d of %d packages failed to install", failures, len(tasks))
 			}
-			if selectedPkg.Repository != "" {
-				fmt.Printf("Repository: %s\n", selectedPkg.Repository)
+
+			if len(chosen) == 1 {
+				fmt.Printf("\n✓ Successfully installed %s\n", titleStyle.Render(chosen[0].Name))
+			} else {
+				fmt.Printf("\n✓ Successfully installed %d packages\n", len(chosen))
 			}
+			return nil
+		},
+	}
 
-			// Ask if user wants to install
-			fmt.Print("\nDo you want to install this package? [y/N] ")
-			var answer string
-			fmt.Scanln(&answer)
-
-			if strings.ToLower(answer) == "y" {
-				// Create installation spinner
-				s = spinner.New()
-				s.Spinner = spinner.Dot
-				s.Style = s.Style.Foreground(s.Style.GetForeground())
-
-				m = searchModel{spinner: s, query: selectedPkg.Name}
-				p = tea.NewProgram(m)
-
-				// Create channel for installation result
-				installErrChan := make(chan error)
-
-				// Start installation in goroutine
-				go func() {
-					// Find the appropriate package manager
-					for _, pm := range mgr.GetManagers() {
-						if pm.GetName() == selectedPkg.Provider {
-							if err := pm.Install(selectedPkg.Name); err != nil {
-								installErrChan <- err
-							} else {
-								installErrChan <- nil
-							}
-							break
-						}
-					}
-				}()
+	cmd.Flags().DurationVar(&providerTimeout, "provider-timeout", manager.DefaultSearchTimeout, "give up on a package manager that takes longer than this to search (0 disables)")
+	cmd.Flags().IntVarP(&jobs, "jobs", "j", defaultJobs, "how many selected packages to install at the same time")
+	cmd.Flags().StringSliceVar(&columnNames, "columns", []string{"name", "version", "provider", "description"}, columnsUsage())
+	cmd.Flags().StringVar(&weightsValue, "weights", "", "ranking weights as name=number pairs, on top of "+ranking.DefaultWeights().String())
+	cmd.Flags().StringSliceVar(&preferNames, "prefer", nil, "package managers to prefer for a project several of them publish, most preferred first")
 
-				// Start spinner
-				go func() {
-					if err := p.Start(); err != nil {
-						fmt.Printf("Error starting spinner: %v\n", err)
-					}
-				}()
+	return cmd
+}
 
-				// Wait for installation result
-				if err := <-installErrChan; err != nil {
-					p.Quit()
-					return fmt.Errorf("installation failed: %v", err)
-				}
+// searchWeights returns the ranking weights set with --weights, else with
+// $PPM_SEARCH_WEIGHTS, on top of the default ones
+func searchWeights(cmd *cobra.Command, value string) (ranking.Weights, error) {
+	if !cmd.Flags().Changed("weights") {
+		weights, err := ranking.ParseWeights(os.Getenv("PPM_SEARCH_WEIGHTS"), ranking.DefaultWeights())
+		if err != nil {
+			return weights, fmt.Errorf("invalid PPM_SEARCH_WEIGHTS: %v", err)
+		}
+		return weights, nil
+	}
+
+	weights, err := ranking.ParseWeights(value, ranking.DefaultWeights())
+	if err != nil {
+		return weights, UsageError(fmt.Errorf("invalid --weights: %v", err))
+	}
+	return weights, nil
+}
 
-				p.Quit()
-				fmt.Printf("\n✓ Successfully installed %s\n", titleStyle.Render(selectedPkg.Name))
+// searchPriority returns the package managers to prefer for a project several
+// of them publish, set with --prefer or else with $PPM_PREFER
+func searchPriority(cmd *cobra.Command, mgr *manager.Manager, names []string) ([]string, error) {
+	source := "--prefer"
+	if !cmd.Flags().Changed("prefer") {
+		source, names = "PPM_PREFER", strings.Split(os.Getenv("PPM_PREFER"), ",")
+	}
+
+	priority := make([]string, 0, len(names))
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if _, ok := mgr.GetManager(name); !ok {
+			err := fmt.Errorf("invalid %s: unknown package manager %q", source, name)
+			if source == "--prefer" {
+				return nil, UsageError(err)
 			}
+			return nil, err
+		}
+		priority = append(priority, name)
+	}
+	return priority, nil
+}
 
-			return nil
-		},
+// searchResult is a search result as written by --output: the preferred
+// package of a project along with the other package managers publishing it
+type searchResult struct {
+	manager.Package `yaml:",inline"`
+	Alternatives    []string `json:"alternatives" yaml:"alternatives"` // As provider:name
+}
+
+func searchResults(groups []ranking.Group) []searchResult {
+	results := make([]searchResult, 0, len(groups))
+	for _, g := range groups {
+		result := searchResult{Package: g.Preferred(), Alternatives: make([]string, 0, len(g.Alternatives()))}
+		for _, alt := range g.Alternatives() {
+			result.Alternatives = append(result.Alternatives, packageKey(alt))
+		}
+		results = append(results, result)
 	}
+	return results
+}
 
-	return cmd
+// renderSearchTable renders grouped search results, the packages other
+// package managers publish of a project as sub-rows of its preferred one
+func renderSearchTable(groups []ranking.Group, cols []packageColumn) string {
+	var pkgs []manager.Package
+	alternative := make(map[int]bool)
+	for _, g := range groups {
+		for i, pkg := range g.Packages {
+			alternative[len(pkgs)] = i > 0
+			pkgs = append(pkgs, pkg)
+		}
+	}
+
+	// Alternatives are marked on the name, wherever --columns put it
+	mark := 0
+	for i, c := range cols {
+		if c.name == "name" {
+			mark = i
+			break
+		}
+	}
+
+	columns, rows := packageTable(pkgs, cols)
+	for i, row := range rows {
+		if alternative[i] {
+			row[mark] = subRowPrefix + row[mark]
+		}
+	}
+	return renderTable(columns, rows)
 }
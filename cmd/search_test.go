@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/RichestHumanAlive/ppm_cli/pkg/manager"
+	"github.com/RichestHumanAlive/ppm_cli/pkg/ranking"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestRenderSearchTableMarksAlternativesByName(t *testing.T) {
+	groups := []ranking.Group{{Packages: []manager.Package{
+		{Name: "black", Version: "24.4.2", Provider: "pip"},
+		{Name: "black", Version: "24.4.2", Provider: "scoop"},
+	}}}
+
+	cols, err := selectPackageColumns([]string{"version", "name", "provider"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := renderSearchTable(groups, cols)
+	if !strings.Contains(out, subRowPrefix+"black") || strings.Contains(out, subRowPrefix+"24.4.2") {
+		t.Errorf("renderSearchTable() does not mark the alternative's name:\n%s", out)
+	}
+}
+
+func TestSearchModelInterrupt(t *testing.T) {
+	cancelled := false
+	m := newSearchModel("black", manager.New(), ranking.DefaultWeights(), nil, func() { cancelled = true })
+
+	for _, key := range []tea.KeyMsg{{Type: tea.KeyEsc}, {Type: tea.KeyCtrlC}} {
+		cancelled = false
+		final, cmd := m.Update(key)
+		if !cancelled || cmd == nil {
+			t.Errorf("Update(%s) did not stop the search and quit", key)
+		}
+		if got, want := final.(searchModel).interrupted, key.Type == tea.KeyCtrlC; got != want {
+			t.Errorf("Update(%s) interrupted = %v, want %v", key, got, want)
+		}
+	}
+}
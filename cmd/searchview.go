@@ -0,0 +1,527 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/RichestHumanAlive/ppm_cli/pkg/manager"
+	"github.com/RichestHumanAlive/ppm_cli/pkg/ranking"
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// searchEventMsg carries a SearchStream event into searchModel
+type searchEventMsg manager.SearchEvent
+
+// searchFinishedMsg tells searchModel that every package manager responded
+type searchFinishedMsg struct{}
+
+var (
+	cursorStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#FF69B4")).
+			Bold(true)
+
+	detailStyle = lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("#808080")).
+			PaddingLeft(1).
+			PaddingRight(1)
+)
+
+// detailHeight is how many lines the detail pane takes, borders included
+const detailHeight = 10
+
+// subRowPrefix marks the packages of a project listed below its preferred one
+const subRowPrefix = "└ "
+
+// searchRow is one line of the search browser
+type searchRow struct {
+	pkg         manager.Package
+	group       int  // Index into searchModel.groups
+	alternative bool // Not the first package shown of its project
+}
+
+// searchModel is a bubbletea search browser. Results are appended, re-ranked
+// and grouped by project as each package manager responds, with a status
+// badge per package manager. The list can be scrolled, filtered by typing,
+// narrowed to some package managers, and packages can be picked for
+// installation.
+type searchModel struct {
+	query     string
+	spinner   spinner.Model
+	filter    textinput.Model
+	providers []string                       // Package managers in registration order
+	events    map[string]manager.SearchEvent // Latest event by package manager
+	ranker    *ranking.Ranker                // Orders found by relevance to the query
+	found     []manager.Package              // Results so far, as the package managers scored them
+	results   []manager.Package              // found, ranked
+	priority  []string                       // Package managers to prefer within a project
+	groups    []ranking.Group                // results, grouped by project
+	hidden    map[string]bool                // Package managers toggled off
+	selected  map[string]bool                // Picked packages by packageKey
+	cursor    int                            // Index into visible()
+	offset    int                            // First visible() row shown
+	width     int
+	height    int
+	searching bool
+	cancel    context.CancelFunc
+
+	interrupted bool // Set when the user pressed Ctrl-C
+
+	chosen []manager.Package // Packages to install, set when the user confirms
+}
+
+func newSearchModel(query string, mgr *manager.Manager, weights ranking.Weights, priority []string, cancel context.CancelFunc) searchModel {
+	s := spinner.New()
+	s.Spinner = spinner.Dot
+
+	filter := textinput.New()
+	filter.Prompt = "/"
+	filter.Placeholder = "type to filter"
+
+	providers := make([]string, 0, len(mgr.GetManagers()))
+	for _, pm := range mgr.GetManagers() {
+		providers = append(providers, pm.GetName())
+	}
+
+	events := make(map[string]manager.SearchEvent)
+	installed := func(provider string) bool {
+		return events[provider].Installed
+	}
+
+	return searchModel{
+		query:     query,
+		spinner:   s,
+		filter:    filter,
+		providers: providers,
+		events:    events,
+		ranker:    searchRanker(weights, installed),
+		priority:  priority,
+		hidden:    make(map[string]bool),
+		selected:  make(map[string]bool),
+		searching: true,
+		cancel:    cancel,
+	}
+}
+
+// packageKey identifies a search result across package managers
+func packageKey(pkg manager.Package) string {
+	return pkg.Provider + ":" + pkg.Name
+}
+
+// visible returns the rows of the results that pass the provider toggles and
+// the filter, each project's packages together
+func (m searchModel) visible() []searchRow {
+	text := strings.ToLower(strings.TrimSpace(m.filter.Value()))
+	rows := make([]searchRow, 0, len(m.results))
+	for g, group := range m.groups {
+		shown := 0
+		for _, pkg := range group.Packages {
+			if m.hidden[pkg.Provider] {
+				continue
+			}
+			if text != "" && !strings.Contains(strings.ToLower(pkg.Name), text) && !strings.Contains(strings.ToLower(pkg.Description), text) {
+				continue
+			}
+			rows = append(rows, searchRow{pkg: pkg, group: g, alternative: shown > 0})
+			shown++
+		}
+	}
+	return rows
+}
+
+// listHeight is how many result rows fit on screen
+func (m searchModel) listHeight() int {
+	if m.height == 0 {
+		return 10
+	}
+	// Header, badges, filter line, blank lines and help around the list
+	if h := m.height - detailHeight - 7; h > 3 {
+		return h
+	}
+	return 3
+}
+
+// clamp keeps the cursor on a visible row and the row on screen
+func (m *searchModel) clamp() {
+	n := len(m.visible())
+	if m.cursor >= n {
+		m.cursor = n - 1
+	}
+	if m.cursor < 0 {
+		m.cursor = 0
+	}
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if h := m.listHeight(); m.cursor >= m.offset+h {
+		m.offset = m.cursor - h + 1
+	}
+}
+
+func (m searchModel) Init() tea.Cmd {
+	return m.spinner.Tick
+}
+
+func (m searchModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		return m.updateKey(msg)
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+	case searchEventMsg:
+		m.events[msg.Provider] = manager.SearchEvent(msg)
+		if msg.Status == manager.StatusDone && len(msg.Packages) > 0 {
+			// Keep the cursor on the same package while rows move
+			var current string
+			if rows := m.visible(); m.cursor < len(rows) {
+				current = packageKey(rows[m.cursor].pkg)
+			}
+			m.found = append(m.found, msg.Packages...)
+			m.results = m.ranker.Rank(m.query, m.found)
+			m.groups = ranking.GroupProjects(m.results, m.priority)
+			for i, row := range m.visible() {
+				if packageKey(row.pkg) == current {
+					m.cursor = i
+				}
+			}
+		}
+	case searchFinishedMsg:
+		m.searching = false
+	default:
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+	}
+	m.clamp()
+	return m, nil
+}
+
+func (m searchModel) updateKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if msg.String() == "ctrl+c" {
+		// The terminal is in raw mode, so Ctrl-C arrives as a key press
+		// rather than SIGINT; stop the running searches and, once the
+		// browser is closed, the whole command
+		m.cancel()
+		m.interrupted = true
+		return m, tea.Quit
+	}
+
+	if m.filter.Focused() {
+		switch msg.String() {
+		case "esc":
+			m.filter.SetValue("")
+			m.filter.Blur()
+		case "enter", "up", "down":
+			m.filter.Blur()
+		default:
+			var cmd tea.Cmd
+			m.filter, cmd = m.filter.Update(msg)
+			m.cursor, m.offset = 0, 0
+			return m, cmd
+		}
+		m.clamp()
+		return m, nil
+	}
+
+	rows := m.visible()
+	switch key := msg.String(); key {
+	case "q", "esc":
+		m.cancel()
+		return m, tea.Quit
+	case "up", "k":
+		m.cursor--
+	case "down", "j":
+		m.cursor++
+	case "pgup":
+		m.cursor -= m.listHeight()
+	case "pgdown":
+		m.cursor += m.listHeight()
+	case "home", "g":
+		m.cursor = 0
+	case "end", "G":
+		m.cursor = len(rows) - 1
+	case "/":
+		m.filter.Focus()
+		return m, textinput.Blink
+	case " ", "x":
+		if m.cursor < len(rows) {
+			row := rows[m.cursor]
+			k := packageKey(row.pkg)
+			if m.selected[k] {
+				delete(m.selected, k)
+			} else {
+				// A project is installed with one package manager only
+				for _, pkg := range m.groups[row.group].Packages {
+					delete(m.selected, packageKey(pkg))
+				}
+				m.selected[k] = true
+			}
+			m.cursor++
+		}
+	case "enter":
+		m.chosen = m.selectedPackages()
+		if len(m.chosen) == 0 && m.cursor < len(rows) {
+			m.chosen = []manager.Package{rows[m.cursor].pkg}
+		}
+		if len(m.chosen) > 0 {
+			m.cancel()
+			return m, tea.Quit
+		}
+	default:
+		// Number keys toggle the package managers shown in the badges
+		if len(key) == 1 && key[0] >= '1' && key[0] <= '9' {
+			if i := int(key[0] - '1'); i < len(m.providers) {
+				m.hidden[m.providers[i]] = !m.hidden[m.providers[i]]
+			}
+		}
+	}
+	m.clamp()
+	return m, nil
+}
+
+// selectedPackages returns the picked packages in ranking order
+func (m searchModel) selectedPackages() []manager.Package {
+	pkgs := make([]manager.Package, 0, len(m.selected))
+	for _, pkg := range m.results {
+		if m.selected[packageKey(pkg)] {
+			pkgs = append(pkgs, pkg)
+		}
+	}
+	return pkgs
+}
+
+func (m searchModel) View() string {
+	if len(m.chosen) > 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	status := "Results for"
+	if m.searching {
+		status = m.spinner.View() + "Searching for"
+	}
+	fmt.Fprintf(&sb, "\n %s '%s'\n %s\n\n", status, m.query, m.badges())
+
+	rows := m.visible()
+	if m.filter.Focused() || m.filter.Value() != "" {
+		sb.WriteString(" " + m.filter.View() + "\n")
+	} else {
+		sb.WriteString(mutedStyle.Render(fmt.Sprintf(" %d of %d packages", len(rows), len(m.results))) + "\n")
+	}
+
+	h := m.listHeight()
+	for i := m.offset; i < m.offset+h; i++ {
+		if i >= len(rows) {
+			sb.WriteString("\n")
+			continue
+		}
+		sb.WriteString(m.renderRow(rows[i], i == m.cursor) + "\n")
+	}
+
+	if m.cursor < len(rows) {
+		sb.WriteString(m.renderDetail(rows[m.cursor]))
+	} else {
+		sb.WriteString(strings.Repeat("\n", detailHeight))
+	}
+
+	help := "↑/↓ move • space select • enter install • / filter • 1-9 toggle package managers • q quit"
+	if n := len(m.selected); n > 0 {
+		help = fmt.Sprintf("%d selected • %s", n, help)
+	}
+	sb.WriteString(mutedStyle.Render(" "+truncateWidth(help, m.lineWidth())) + "\n")
+	return sb.String()
+}
+
+// lineWidth is the usable width of a line
+func (m searchModel) lineWidth() int {
+	if m.width == 0 {
+		return 100
+	}
+	return m.width - 2
+}
+
+// renderRow renders one result as a list line
+func (m searchModel) renderRow(row searchRow, current bool) string {
+	pkg := row.pkg
+	pointer, check := "  ", "[ ]"
+	if current {
+		pointer = cursorStyle.Render("› ")
+	}
+	if m.selected[packageKey(pkg)] {
+		check = successStyle.Render("[x]")
+	}
+
+	nameStyle := descStyle
+	if current {
+		nameStyle = titleStyle
+	}
+
+	name := pkg.Name
+	if row.alternative {
+		name = subRowPrefix + name
+	}
+
+	// Pointer, checkbox and the padded name, version and provider columns
+	fixed := 2 + 4 + 31 + 13 + 7
+	return fmt.Sprintf("%s%s %s %s %s %s", pointer, check,
+		nameStyle.Render(padRight(truncateWidth(name, 30), 30)),
+		versionStyle.Render(padRight(truncateWidth(pkg.Version, 12), 12)),
+		providerStyle.Render(padRight(pkg.Provider, 6)),
+		mutedStyle.Render(truncateWidth(firstLine(pkg.Description), m.lineWidth()-fixed)))
+}
+
+// renderDetail renders the detail pane of a row's package, naming the other
+// package managers that publish its project
+func (m searchModel) renderDetail(row searchRow) string {
+	pkg := row.pkg
+	w := m.lineWidth() - 4
+	field := func(label, value string) string {
+		if value == "" {
+			value = "-"
+		}
+		return mutedStyle.Render(fmt.Sprintf("%-11s", label)) + truncateWidth(value, w-11)
+	}
+
+	downloads := ""
+	if pkg.Downloads > 0 {
+		downloads = fmt.Sprintf("%d per month", pkg.Downloads)
+	}
+
+	title := titleStyle.Render(truncateWidth(pkg.Name, w/2)) + " " + versionStyle.Render(pkg.Version) + " " + providerStyle.Render(pkg.Provider)
+	var others []string
+	for _, other := range m.groups[row.group].Packages {
+		if packageKey(other) != packageKey(pkg) {
+			others = append(others, other.Provider)
+		}
+	}
+	if len(others) > 0 {
+		title += mutedStyle.Render(truncateWidth("  also on "+strings.Join(others, ", "), w-lipgloss.Width(title)))
+	}
+
+	lines := []string{
+		title,
+		descStyle.Render(truncateWidth(firstLine(pkg.Description), w)),
+		field("Author", pkg.Author),
+		field("License", pkg.License),
+		field("Homepage", pkg.Homepage),
+		field("Repository", pkg.Repository),
+		field("Downloads", downloads),
+		field("Released", pkg.Updated),
+	}
+	return detailStyle.Width(w+2).Render(strings.Join(lines, "\n")) + "\n"
+}
+
+// badges renders one status badge per package manager, numbered for toggling
+func (m searchModel) badges() string {
+	badges := make([]string, 0, len(m.providers))
+	for i, provider := range m.providers {
+		badge := providerBadge(provider, m.events[provider], m.spinner.View())
+		if m.hidden[provider] {
+			badge = mutedStyle.Render(provider + " hidden")
+		}
+		badges = append(badges, mutedStyle.Render(fmt.Sprintf("%d ", i+1))+badge)
+	}
+	return strings.Join(badges, "   ")
+}
+
+// providerBadge renders the search status of one package manager
+func providerBadge(provider string, ev manager.SearchEvent, spin string) string {
+	name := providerStyle.Render(provider)
+	switch ev.Status {
+	case manager.StatusDone:
+		return fmt.Sprintf("%s %s", name, successStyle.Render(fmt.Sprintf("✓ %d", len(ev.Packages))))
+	case manager.StatusFailed:
+		return fmt.Sprintf("%s %s", name, errorStyle.Render("✗ failed"))
+	case manager.StatusUnavailable:
+		return fmt.Sprintf("%s %s", name, mutedStyle.Render("– unavailable"))
+	default:
+		return fmt.Sprintf("%s %s", name, mutedStyle.Render(strings.TrimSpace(spin)+" searching"))
+	}
+}
+
+// searchRanker returns the ranker of search results, counting the package
+// managers installed on this host as available
+func searchRanker(weights ranking.Weights, installed func(provider string) bool) *ranking.Ranker {
+	return ranking.New(ranking.WithWeights(weights), ranking.WithAvailable(installed))
+}
+
+// browseSearch runs the search browser while the package managers are
+// searched. It returns the packages the user picked for installation, none
+// when the user quit, along with the package managers that failed.
+func browseSearch(ctx context.Context, mgr *manager.Manager, query string, weights ranking.Weights, priority []string) ([]manager.Package, *manager.MultiError, error) {
+	searchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	p := tea.NewProgram(newSearchModel(query, mgr, weights, priority, cancel), tea.WithAltScreen(), tea.WithContext(ctx))
+
+	var failed []*manager.ProviderError
+	searched := make(chan struct{})
+	go func() {
+		defer close(searched)
+		mgr.SearchStream(searchCtx, query, func(ev manager.SearchEvent) {
+			// Searches still running when the browser closes fail with
+			// the cancellation, which is not worth reporting
+			if ev.Status == manager.StatusFailed && searchCtx.Err() == nil {
+				failed = append(failed, &manager.ProviderError{Provider: ev.Provider, Err: ev.Err})
+			}
+			p.Send(searchEventMsg(ev))
+		})
+		p.Send(searchFinishedMsg{})
+	}()
+
+	final, err := p.Run()
+	cancel()
+	<-searched
+
+	if m, ok := final.(searchModel); ok && m.interrupted {
+		interrupt(ctx)
+	}
+	if ctx.Err() != nil {
+		return nil, nil, interruptedError(ctx, ctx.Err())
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("search browser failed: %v", err)
+	}
+
+	var multi *manager.MultiError
+	if len(failed) > 0 {
+		multi = &manager.MultiError{Errors: failed}
+	}
+	return final.(searchModel).chosen, multi, nil
+}
+
+// collectSearch searches every package manager without a UI and returns the
+// ranked results along with the package managers that failed, if any
+func collectSearch(ctx context.Context, mgr *manager.Manager, query string, weights ranking.Weights) ([]manager.Package, *manager.MultiError, error) {
+	events := make(map[string]manager.SearchEvent)
+	mgr.SearchStream(ctx, query, func(ev manager.SearchEvent) {
+		events[ev.Provider] = ev
+	})
+	if ctx.Err() != nil {
+		return nil, nil, interruptedError(ctx, ctx.Err())
+	}
+
+	var (
+		results []manager.Package
+		failed  []*manager.ProviderError
+	)
+	for _, pm := range mgr.GetManagers() {
+		ev := events[pm.GetName()]
+		results = append(results, ev.Packages...)
+		if ev.Err != nil {
+			failed = append(failed, &manager.ProviderError{Provider: ev.Provider, Err: ev.Err})
+		}
+	}
+	installed := func(provider string) bool { return events[provider].Installed }
+	results = searchRanker(weights, installed).Rank(query, results)
+
+	var multi *manager.MultiError
+	if len(failed) > 0 {
+		multi = &manager.MultiError{Errors: failed}
+	}
+	return results, multi, nil
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// spinnerModel is a minimal bubbletea model that shows a spinner next to a message
+type spinnerModel struct {
+	spinner  spinner.Model
+	message  string
+	cancel   context.CancelFunc
+	quitting bool
+}
+
+func (m spinnerModel) Init() tea.Cmd {
+	return m.spinner.Tick
+}
+
+func (m spinnerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c":
+			// The terminal is in raw mode, so Ctrl-C arrives as a key
+			// press rather than SIGINT; stop the whole command
+			m.cancel()
+			m.quitting = true
+			return m, tea.Quit
+		case "q":
+			m.quitting = true
+			return m, tea.Quit
+		}
+	default:
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+func (m spinnerModel) View() string {
+	if m.quitting {
+		return ""
+	}
+	return fmt.Sprintf("\n %s %s\n", m.spinner.View(), m.message)
+}
+
+// runWithSpinner runs fn while displaying a spinner with the given message,
+// giving fn the --timeout carried by ctx. Pressing Ctrl-C interrupts the command ctx belongs to, and errors caused by a
+// cancelled or timed out context are reported as such. The spinner is torn down
+// before runWithSpinner returns so callers can print their own output
+// afterwards. When PPM is not interactive, the message is printed to stderr
+// as a plain line instead.
+func runWithSpinner(ctx context.Context, message string, fn func(ctx context.Context) error) error {
+	ctx, cancel := withOperationTimeout(ctx)
+	defer cancel()
+
+	if !isInteractive() {
+		fmt.Fprintln(os.Stderr, message)
+		if err := fn(ctx); err != nil {
+			return interruptedError(ctx, err)
+		}
+		return nil
+	}
+
+	s := spinner.New()
+	s.Spinner = spinner.Dot
+	s.Style = s.Style.Foreground(s.Style.GetForeground())
+
+	p := tea.NewProgram(spinnerModel{spinner: s, message: message, cancel: func() { interrupt(ctx); cancel() }}, tea.WithContext(ctx))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if _, err := p.Run(); err != nil && !errors.Is(err, tea.ErrProgramKilled) {
+			fmt.Printf("Error starting spinner: %v\n", err)
+		}
+	}()
+
+	err := fn(ctx)
+	p.Quit()
+	<-done
+
+	if err != nil {
+		return interruptedError(ctx, err)
+	}
+	return nil
+}
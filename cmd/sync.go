@@ -0,0 +1,374 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"sync"
+
+	"github.com/RichestHumanAlive/ppm_cli/pkg/lockfile"
+	"github.com/RichestHumanAlive/ppm_cli/pkg/manager"
+	"github.com/RichestHumanAlive/ppm_cli/pkg/manifest"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+)
+
+// pruneExempt lists the packages --prune never removes because the package
+// manager itself depends on them
+var pruneExempt = map[string][]string{
+	"npm": {"npm", "corepack"},
+	"pip": {"pip", "setuptools", "wheel"},
+}
+
+// syncResult is the outcome of applying one step of a sync plan
+type syncResult struct {
+	step manifest.Step
+	err  error
+}
+
+// planSync diffs every section of the manifest against the host. With prune,
+// installed packages the manifest does not declare are removed too; only
+// packages nothing else depends on are candidates.
+func planSync(ctx context.Context, mgr *manager.Manager, m *manifest.File, prune bool) []manifest.Step {
+	var (
+		mu    sync.Mutex
+		wg    sync.WaitGroup
+		steps []manifest.Step
+	)
+
+	add := func(s []manifest.Step) {
+		mu.Lock()
+		steps = append(steps, s...)
+		mu.Unlock()
+	}
+
+	for _, provider := range m.Providers() {
+		declared := m.Packages[provider]
+
+		pm, _ := mgr.GetManager(provider)
+		if !manager.SupportsOS(pm, runtime.GOOS) {
+			add(manifest.Blocked(provider, declared, manifest.Unsupported, fmt.Sprintf("%s does not run on %s", provider, runtime.GOOS)))
+			continue
+		}
+
+		wg.Add(1)
+		go func(provider string, pm manager.PackageManager, declared []manager.PackageSpec) {
+			defer wg.Done()
+			if !pm.IsAvailable(ctx) {
+				add(manifest.Blocked(provider, declared, manifest.Unavailable, fmt.Sprintf("%s is not installed", provider)))
+				return
+			}
+			installed, err := pm.ListInstalled(ctx)
+			if err != nil {
+				add(manifest.Blocked(provider, declared, manifest.Unavailable, "could not list installed packages: "+firstLine(err.Error())))
+				return
+			}
+
+			var removable []manager.Package
+			if prune {
+				removable, err = pruneCandidates(ctx, pm)
+				if err != nil {
+					add(manifest.Blocked(provider, declared, manifest.Unavailable, "could not list removable packages: "+firstLine(err.Error())))
+					return
+				}
+			}
+			add(manifest.Plan(provider, declared, installed, removable))
+		}(provider, pm, declared)
+	}
+	wg.Wait()
+
+	manifest.SortSteps(steps)
+	return steps
+}
+
+// pruneCandidates returns the installed packages --prune may remove. Package
+// managers that cannot tell dependencies from the packages installed on
+// request are never pruned.
+func pruneCandidates(ctx context.Context, pm manager.PackageManager) ([]manager.Package, error) {
+	lister, ok := pm.(manager.TopLevelLister)
+	if !ok {
+		return nil, nil
+	}
+	installed, err := lister.ListTopLevel(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]manager.Package, 0, len(installed))
+	for _, pkg := range installed {
+		exempt := false
+		for _, name := range pruneExempt[pm.GetName()] {
+			exempt = exempt || manager.SameName(pm.GetName(), name, pkg.Name)
+		}
+		if !exempt {
+			candidates = append(candidates, pkg)
+		}
+	}
+	return candidates, nil
+}
+
+// warnSkippedProviders prints one line per package manager whose declared
+// packages cannot be synced on this host. Package managers that do not run on
+// this OS are expected in a cross-platform manifest and only get a note.
+func warnSkippedProviders(steps []manifest.Step) {
+	counts := make(map[string]int)
+	order := make([]manifest.Step, 0)
+	for _, s := range steps {
+		if s.Action != manifest.Unsupported && s.Action != manifest.Unavailable {
+			continue
+		}
+		if counts[s.Spec.Provider] == 0 {
+			order = append(order, s)
+		}
+		counts[s.Spec.Provider]++
+	}
+
+	for _, s := range order {
+		level := "warning"
+		if s.Action == manifest.Unsupported {
+			level = "note"
+		}
+		fmt.Fprintf(os.Stderr, "%s: skipping %d %s packages: %s\n", level, counts[s.Spec.Provider], s.Spec.Provider, s.Reason)
+	}
+}
+
+func syncActionStyle(action manifest.Action) lipgloss.Style {
+	switch action {
+	case manifest.Install:
+		return successStyle
+	case manifest.Upgrade:
+		return versionStyle
+	case manifest.Keep, manifest.Unsupported:
+		return mutedStyle
+	default:
+		return errorStyle
+	}
+}
+
+func renderSyncPlan(steps []manifest.Step) string {
+	rows := make([][]string, 0, len(steps))
+	for _, s := range steps {
+		rows = append(rows, []string{string(s.Action), s.Spec.Provider, s.Spec.Name, s.Installed, s.Spec.Constraint, s.Reason})
+	}
+
+	return renderTable([]column{
+		{header: "Action", rowStyle: func(row int) lipgloss.Style { return syncActionStyle(steps[row].Action) }},
+		{header: "Provider", style: providerStyle},
+		{header: "Package", style: titleStyle},
+		{header: "Installed", style: versionStyle},
+		{header: "Wanted", style: versionStyle},
+		{header: "Note", style: descStyle, overflow: overflowWrap},
+	}, rows)
+}
+
+func renderSyncReport(results []syncResult) string {
+	rows := make([][]string, 0, len(results))
+	for _, r := range results {
+		status, details := "done", ""
+		if r.err != nil {
+			status, details = "failed", firstLine(r.err.Error())
+		}
+		rows = append(rows, []string{string(r.step.Action), r.step.Spec.Provider, r.step.Spec.String(), status, details})
+	}
+
+	return renderTable([]column{
+		{header: "Action", rowStyle: func(row int) lipgloss.Style { return syncActionStyle(results[row].step.Action) }},
+		{header: "Provider", style: providerStyle},
+		{header: "Package", style: titleStyle},
+		{header: "Status", rowStyle: func(row int) lipgloss.Style { return resultStyle(results[row].err) }},
+		{header: "Details", style: descStyle, overflow: overflowWrap},
+	}, rows)
+}
+
+// lockedSpec pins a declared spec to its version in the lock, provided the
+// locked version is inside the declared range
+func lockedSpec(lock *lockfile.File, spec manager.PackageSpec) manager.PackageSpec {
+	if lock == nil {
+		return spec
+	}
+	locked, ok := lock.Find(spec.Provider, spec.Name)
+	if !ok {
+		return spec
+	}
+	ok, err := manager.Satisfies(locked.Version, spec.Constraint)
+	if err != nil {
+		// A dist-tag such as "next" cannot be checked against the lock
+		return spec
+	}
+	if !ok {
+		fmt.Fprintf(os.Stderr, "warning: %s pins %s at %s, outside %s; ignoring the lock\n", lockfile.DefaultPath, spec.Name, locked.Version, spec.Constraint)
+		return spec
+	}
+	spec.Constraint = locked.Version
+	return spec
+}
+
+// applySync installs, upgrades and removes the packages of every pending
+// step, one at a time, dispatching each to its package manager
+func applySync(ctx context.Context, mgr *manager.Manager, steps []manifest.Step, lock *lockfile.File) ([]syncResult, error) {
+	pending := make([]manifest.Step, 0, len(steps))
+	for _, s := range steps {
+		if s.Pending() {
+			pending = append(pending, s)
+		}
+	}
+
+	results := make([]syncResult, 0, len(pending))
+	for i, s := range pending {
+		pm, _ := mgr.GetManager(s.Spec.Provider)
+
+		var (
+			message string
+			run     func(ctx context.Context) error
+		)
+		switch s.Action {
+		case manifest.Remove:
+			message = fmt.Sprintf("[%d/%d] Removing %s from %s...", i+1, len(pending), s.Spec.Name, s.Spec.Provider)
+			run = func(ctx context.Context) error { return pm.Remove(ctx, s.Spec.Name) }
+		default:
+			spec := lockedSpec(lock, s.Spec)
+			verb := "Installing"
+			if s.Action == manifest.Upgrade {
+				verb = "Upgrading"
+			}
+			message = fmt.Sprintf("[%d/%d] %s %s with %s...", i+1, len(pending), verb, spec, s.Spec.Provider)
+			run = func(ctx context.Context) error { return pm.Install(ctx, spec) }
+		}
+
+		err := runWithSpinner(ctx, message, run)
+		if ctx.Err() != nil || errors.Is(err, errInterrupted) || errors.Is(err, errTimedOut) {
+			// Interrupted: report what was done so far
+			return results, err
+		}
+		results = append(results, syncResult{step: s, err: err})
+	}
+	return results, nil
+}
+
+func NewSyncCmd() *cobra.Command {
+	var (
+		file   string
+		prune  bool
+		dryRun bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "sync",
+		Short: "Install, upgrade and prune packages to match ppm.yaml",
+		Long: `Converge the installed packages to the project manifest, ppm.yaml.
+
+The manifest lists package specs by package manager:
+
+  npm:
+    - typescript@5
+  pip:
+    - black
+    - requests>=2.31,<3
+
+Declared packages that are missing are installed and installed packages
+whose version is outside the declared range are upgraded (or downgraded) into
+it. With --prune, installed packages the manifest does not declare are
+removed from the package managers it lists; packages installed only as
+dependencies and the package managers' own packages are kept.
+
+Sections of package managers that do not run on this operating system are
+skipped. When ppm.lock exists in the working directory, packages are
+installed at their locked version if it is inside the declared range.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			m, err := manifest.Load(file)
+			if err != nil {
+				return err
+			}
+
+			mgr := newManager()
+			for _, provider := range m.Providers() {
+				if _, ok := mgr.GetManager(provider); !ok {
+					return fmt.Errorf("%s: unknown package manager: %s", file, provider)
+				}
+			}
+
+			lock, err := lockfile.LoadIfExists(lockfile.DefaultPath)
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := operationContext(cmd)
+			defer cancel()
+
+			var steps []manifest.Step
+			err = runWithSpinner(ctx, "Comparing with installed packages...", func(ctx context.Context) error {
+				steps = planSync(ctx, mgr, m, prune)
+				return ctx.Err()
+			})
+			if err != nil {
+				return err
+			}
+
+			if len(steps) == 0 {
+				fmt.Printf("%s declares no packages\n", file)
+				return nil
+			}
+
+			pending, unavailable := 0, 0
+			for _, s := range steps {
+				if s.Pending() {
+					pending++
+				}
+				if s.Action == manifest.Unavailable {
+					unavailable++
+				}
+			}
+
+			fmt.Printf("\nSync plan for %s\n\n", file)
+			fmt.Print(renderSyncPlan(steps))
+			warnSkippedProviders(steps)
+
+			if pending == 0 {
+				if unavailable > 0 {
+					return fmt.Errorf("%d declared packages could not be synced", unavailable)
+				}
+				fmt.Printf("\n✓ Installed packages match %s\n", file)
+				return nil
+			}
+			if dryRun {
+				fmt.Printf("\n%d changes planned (dry run, nothing was changed)\n", pending)
+				return nil
+			}
+
+			if err := confirmChanges(pending); err != nil {
+				return err
+			}
+
+			results, err := applySync(ctx, mgr, steps, lock)
+			if len(results) > 0 {
+				fmt.Printf("\nSync report\n\n")
+				fmt.Print(renderSyncReport(results))
+			}
+			if err != nil {
+				return err
+			}
+
+			failed := 0
+			for _, r := range results {
+				if r.err != nil {
+					failed++
+				}
+			}
+			if failed > 0 || unavailable > 0 {
+				return fmt.Errorf("%d of %d changes failed, %d declared packages could not be synced", failed, len(results), unavailable)
+			}
+
+			fmt.Printf("\n✓ Applied %d changes\n", len(results))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&file, "file", "f", manifest.DefaultPath, "project manifest to sync with")
+	cmd.Flags().BoolVar(&prune, "prune", false, "remove installed packages the manifest does not declare")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "only print the plan")
+
+	return cmd
+}
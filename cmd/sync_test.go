@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/RichestHumanAlive/ppm_cli/pkg/manager"
+	"github.com/RichestHumanAlive/ppm_cli/pkg/manifest"
+)
+
+func TestApplySyncStopsWhenInterrupted(t *testing.T) {
+	for name, change := range interruptions {
+		t.Run(name, func(t *testing.T) {
+			pm := &stubManager{name: "pip", change: change}
+			mgr := manager.New()
+			mgr.RegisterManager(pm)
+
+			steps := []manifest.Step{
+				{Spec: manager.PackageSpec{Provider: "pip", Name: "requests"}, Action: manifest.Remove},
+				{Spec: manager.PackageSpec{Provider: "pip", Name: "black"}, Action: manifest.Install},
+			}
+			results, err := applySync(stubCommandContext(t), mgr, steps, nil)
+			if !errors.Is(err, errInterrupted) {
+				t.Errorf("applySync() error = %v, want %v", err, errInterrupted)
+			}
+			if len(results) != 0 || len(pm.changed) != 1 {
+				t.Errorf("applySync() changed %v with results %v, want it to stop after requests", pm.changed, results)
+			}
+		})
+	}
+}
+
+// topLevelManager is a stubManager that lists its top-level packages
+type topLevelManager struct {
+	stubManager
+	topLevel []manager.Package
+}
+
+func (m *topLevelManager) ListTopLevel(ctx context.Context) ([]manager.Package, error) {
+	return m.topLevel, nil
+}
+
+func TestPruneCandidates(t *testing.T) {
+	pip := &topLevelManager{stubManager: stubManager{name: "pip"}, topLevel: []manager.Package{{Name: "black"}, {Name: "pip"}, {Name: "Wheel"}}}
+	got, err := pruneCandidates(context.Background(), pip)
+	if err != nil || len(got) != 1 || got[0].Name != "black" {
+		t.Errorf("pruneCandidates(pip) = %v, %v, want black only", got, err)
+	}
+
+	// Without top-level listing, dependencies could be removed
+	got, err = pruneCandidates(context.Background(), &stubManager{name: "scoop"})
+	if err != nil || len(got) != 0 {
+		t.Errorf("pruneCandidates(scoop) = %v, %v, want nothing", got, err)
+	}
+}
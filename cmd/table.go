@@ -0,0 +1,246 @@
+package cmd
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/reflow/truncate"
+	"github.com/muesli/reflow/wordwrap"
+	"github.com/muesli/reflow/wrap"
+	"golang.org/x/term"
+)
+
+// overflow says what a column does when the table is wider than the terminal
+type overflow int
+
+const (
+	overflowNone     overflow = iota // Keep the cells whole
+	overflowTruncate                 // Cut the cells with an ellipsis
+	overflowWrap                     // Wrap the cells onto several lines
+)
+
+// minShrinkWidth is the narrowest a truncated or wrapped column gets
+const minShrinkWidth = 8
+
+// column describes one column of a table
+type column struct {
+	header   string
+	style    lipgloss.Style               // Style of the column's cells
+	rowStyle func(row int) lipgloss.Style // Overrides style per row when set
+	overflow overflow
+	// priority orders the columns that may be dropped when the table does
+	// not fit even with every column shrunk: the highest priority number
+	// goes first. Columns with priority 0 are always shown.
+	priority int
+}
+
+func (c column) cellStyle(row int) lipgloss.Style {
+	if c.rowStyle != nil {
+		return c.rowStyle(row)
+	}
+	return c.style
+}
+
+// resultStyle styles the status of an operation that failed with err, if any
+func resultStyle(err error) lipgloss.Style {
+	if err != nil {
+		return errorStyle
+	}
+	return successStyle
+}
+
+// terminalWidth returns the width tables have to fit in: the width of the
+// terminal, else $COLUMNS, else 0 for no limit
+func terminalWidth() int {
+	if w, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil && w > 0 {
+		return w
+	}
+	if w, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && w > 0 {
+		return w
+	}
+	return 0
+}
+
+// renderTable renders rows of cells as a bordered table that fits the
+// terminal. Widths are measured in terminal cells, ignoring ANSI escapes, so
+// wide characters and styled text stay aligned. When the table is too wide,
+// truncating and wrapping columns are shrunk first, then optional columns are
+// dropped by priority.
+func renderTable(columns []column, rows [][]string) string {
+	return renderTableWidth(columns, rows, terminalWidth())
+}
+
+// renderTableWidth is renderTable for a given width, 0 meaning no limit
+func renderTableWidth(columns []column, rows [][]string, width int) string {
+	natural := make([]int, len(columns))
+	for i, c := range columns {
+		natural[i] = lipgloss.Width(c.header)
+		for _, row := range rows {
+			if i < len(row) && lipgloss.Width(row[i]) > natural[i] {
+				natural[i] = lipgloss.Width(row[i])
+			}
+		}
+	}
+
+	visible := make([]int, 0, len(columns))
+	for i := range columns {
+		visible = append(visible, i)
+	}
+	widths, fits := fitColumns(columns, natural, visible, width)
+	for !fits {
+		drop := -1
+		for j, i := range visible {
+			if p := columns[i].priority; p > 0 && (drop < 0 || p >= columns[visible[drop]].priority) {
+				drop = j
+			}
+		}
+		if drop < 0 {
+			// Nothing left to drop: keep the columns shrunk as far as they
+			// go and let the terminal wrap the rest
+			break
+		}
+		visible = append(visible[:drop], visible[drop+1:]...)
+		widths, fits = fitColumns(columns, natural, visible, width)
+	}
+
+	border := func(left, mid, right string) string {
+		var sb strings.Builder
+		sb.WriteString(left)
+		for j := range visible {
+			sb.WriteString(strings.Repeat("─", widths[j]+2))
+			if j < len(visible)-1 {
+				sb.WriteString(mid)
+			}
+		}
+		sb.WriteString(right + "\n")
+		return sb.String()
+	}
+
+	var sb strings.Builder
+	sb.WriteString(border("┌", "┬", "┐"))
+
+	sb.WriteString("│")
+	for j, i := range visible {
+		sb.WriteString(cellStyle.Render(headerStyle.Render(padRight(truncateWidth(columns[i].header, widths[j]), widths[j]))))
+		sb.WriteString("│")
+	}
+	sb.WriteString("\n")
+
+	sb.WriteString(border("├", "┼", "┤"))
+
+	for r, row := range rows {
+		// A row is as tall as its most wrapped cell
+		cells := make([][]string, len(visible))
+		height := 1
+		for j, i := range visible {
+			cell := ""
+			if i < len(row) {
+				cell = row[i]
+			}
+			cells[j] = fitCell(cell, widths[j], columns[i].overflow)
+			if len(cells[j]) > height {
+				height = len(cells[j])
+			}
+		}
+
+		for line := 0; line < height; line++ {
+			sb.WriteString("│")
+			for j, i := range visible {
+				text := ""
+				if line < len(cells[j]) {
+					text = cells[j][line]
+				}
+				sb.WriteString(cellStyle.Render(columns[i].cellStyle(r).Render(padRight(text, widths[j]))))
+				sb.WriteString("│")
+			}
+			sb.WriteString("\n")
+		}
+	}
+
+	sb.WriteString(border("└", "┴", "┘"))
+
+	return sb.String()
+}
+
+// fitColumns shrinks the visible columns to fit in width and returns their
+// widths, reporting whether shrinking was enough
+func fitColumns(columns []column, natural, visible []int, width int) ([]int, bool) {
+	widths := make([]int, len(visible))
+	total := 1
+	for j, i := range visible {
+		widths[j] = natural[i]
+		total += widths[j] + 3 // Padding and border
+	}
+	if width <= 0 {
+		return widths, true
+	}
+
+	// Take one cell at a time from the widest shrinkable column, so that
+	// shrunk columns end up about as wide as each other
+	for total > width {
+		widest := -1
+		for j, i := range visible {
+			if columns[i].overflow == overflowNone || widths[j] <= minWidth(columns[i], natural[i]) {
+				continue
+			}
+			if widest < 0 || widths[j] > widths[widest] {
+				widest = j
+			}
+		}
+		if widest < 0 {
+			return widths, false
+		}
+		widths[widest]--
+		total--
+	}
+	return widths, true
+}
+
+// minWidth is the narrowest a column may be shrunk to
+func minWidth(c column, natural int) int {
+	min := lipgloss.Width(c.header)
+	if min < minShrinkWidth {
+		min = minShrinkWidth
+	}
+	if natural < min {
+		return natural
+	}
+	return min
+}
+
+// fitCell breaks a cell into the lines it takes in a column of the given width
+func fitCell(cell string, width int, o overflow) []string {
+	if lipgloss.Width(cell) <= width {
+		return []string{cell}
+	}
+	switch o {
+	case overflowTruncate:
+		return []string{truncateWidth(cell, width)}
+	case overflowWrap:
+		return strings.Split(wrap.String(wordwrap.String(cell, width), width), "\n")
+	default:
+		return []string{cell}
+	}
+}
+
+// truncateWidth shortens s to at most w terminal cells, marking the cut with
+// an ellipsis. ANSI escapes do not count towards the width.
+func truncateWidth(s string, w int) string {
+	if w <= 0 {
+		return ""
+	}
+	if lipgloss.Width(s) <= w {
+		return s
+	}
+	return truncate.StringWithTail(s, uint(w), "…")
+}
+
+// padRight pads s with spaces to w terminal cells
+func padRight(s string, w int) string {
+	if n := w - lipgloss.Width(s); n > 0 {
+		return s + strings.Repeat(" ", n)
+	}
+	return s
+}
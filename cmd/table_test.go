@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestRenderTableWidth(t *testing.T) {
+	name := column{header: "Name"}
+	version := column{header: "Version", priority: 1}
+	desc := column{header: "Description", overflow: overflowTruncate, priority: 2}
+	wrapped := column{header: "Description", overflow: overflowWrap}
+
+	rows := [][]string{
+		{"prettier", "3.2.5", "An opinionated code formatter"},
+		{"eslint", "8.57.0", "An AST-based pattern checker for JavaScript"},
+	}
+
+	tests := []struct {
+		name    string
+		columns []column
+		rows    [][]string
+		width   int
+		want    int      // Width of every line
+		height  int      // Lines between the header and the bottom border
+		shown   []string // Text that must appear
+		hidden  []string // Text that must not appear
+	}{
+		{
+			name:    "no limit",
+			columns: []column{name, version, desc},
+			rows:    rows,
+			want:    1 + (8 + 3) + (7 + 3) + (43 + 3),
+			height:  2,
+			shown:   []string{"An AST-based pattern checker for JavaScript"},
+		},
+		{
+			name:    "fits",
+			columns: []column{name, version, desc},
+			rows:    rows,
+			width:   200,
+			want:    1 + (8 + 3) + (7 + 3) + (43 + 3),
+			height:  2,
+		},
+		{
+			name:    "truncates to the width",
+			columns: []column{name, version, desc},
+			rows:    rows,
+			width:   40,
+			want:    40,
+			height:  2,
+			shown:   []string{"Version", "…"},
+			hidden:  []string{"JavaScript"},
+		},
+		{
+			name:    "drops the highest priority first",
+			columns: []column{name, version, desc},
+			rows:    rows,
+			width:   24,
+			want:    1 + (8 + 3) + (7 + 3),
+			height:  2,
+			shown:   []string{"Name", "Version"},
+			hidden:  []string{"Description"},
+		},
+		{
+			name:    "keeps columns without priority",
+			columns: []column{name, version, desc},
+			rows:    rows,
+			width:   5,
+			want:    1 + (8 + 3),
+			height:  2,
+			shown:   []string{"prettier", "eslint"},
+			hidden:  []string{"Version"},
+		},
+		{
+			name:    "wraps",
+			columns: []column{name, wrapped},
+			rows:    [][]string{{rows[0][0], rows[0][2]}, {rows[1][0], rows[1][2]}},
+			width:   30,
+			want:    30,
+			height:  5,
+			shown:   []string{"An opinionated", "JavaScript"},
+			hidden:  []string{"…"},
+		},
+		{
+			name:    "measures wide characters and escapes in cells",
+			columns: []column{name, wrapped},
+			rows: [][]string{
+				{"日本語", "\x1b[31mred\x1b[0m words"},
+				{"n", "ok"},
+			},
+			want:   1 + (6 + 3) + (11 + 3),
+			height: 2,
+			shown:  []string{"日本語", "\x1b[31mred\x1b[0m"},
+		},
+		{
+			name:    "short rows",
+			columns: []column{name, version},
+			rows:    [][]string{{"prettier"}},
+			want:    1 + (8 + 3) + (7 + 3),
+			height:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := renderTableWidth(tt.columns, tt.rows, tt.width)
+			lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+
+			for _, line := range lines {
+				if w := lipgloss.Width(line); w != tt.want {
+					t.Errorf("line %q is %d cells wide, want %d", line, w, tt.want)
+				}
+			}
+			// Top border, header, separator and bottom border
+			if height := len(lines) - 4; height != tt.height {
+				t.Errorf("table has %d row lines, want %d:\n%s", height, tt.height, out)
+			}
+			for _, s := range tt.shown {
+				if !strings.Contains(out, s) {
+					t.Errorf("table does not show %q:\n%s", s, out)
+				}
+			}
+			for _, s := range tt.hidden {
+				if strings.Contains(out, s) {
+					t.Errorf("table shows %q:\n%s", s, out)
+				}
+			}
+		})
+	}
+}
+
+func TestTruncateWidth(t *testing.T) {
+	tests := []struct {
+		s    string
+		w    int
+		want string
+	}{
+		{"prettier", 10, "prettier"},
+		{"prettier", 5, "pret…"},
+		{"日本語テキスト", 7, "日本語…"},
+		{"prettier", 0, ""},
+	}
+	for _, tt := range tests {
+		if got := truncateWidth(tt.s, tt.w); got != tt.want {
+			t.Errorf("truncateWidth(%q, %d) = %q, want %q", tt.s, tt.w, got, tt.want)
+		}
+	}
+}
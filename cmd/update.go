@@ -0,0 +1,219 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/RichestHumanAlive/ppm_cli/pkg/manager"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+)
+
+// updateResult records the outcome of a single update operation
+type updateResult struct {
+	provider string
+	target   string
+	err      error
+	skipped  bool
+}
+
+func (r updateResult) status() string {
+	switch {
+	case r.skipped:
+		return "skipped"
+	case r.err != nil:
+		return "failed"
+	default:
+		return "updated"
+	}
+}
+
+// firstLine returns the first non-empty line of s
+func firstLine(s string) string {
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// updateAll updates every package of every available package manager concurrently
+func updateAll(ctx context.Context, mgr *manager.Manager) []updateResult {
+	pms := mgr.GetManagers()
+	results := make([]updateResult, len(pms))
+
+	var wg sync.WaitGroup
+	for i, pm := range pms {
+		results[i] = updateResult{provider: pm.GetName(), target: "all"}
+
+		wg.Add(1)
+		go func(i int, pm manager.PackageManager) {
+			defer wg.Done()
+			if !pm.IsAvailable(ctx) {
+				results[i].skipped = true
+				return
+			}
+			results[i].err = pm.Update(ctx, "")
+		}(i, pm)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// updateTarget is a named package resolved to the package manager that has it installed
+type updateTarget struct {
+	pm   manager.PackageManager
+	name string
+}
+
+// resolveUpdateTargets resolves each named package to the package manager
+// that has it installed. Packages that cannot be resolved are returned as
+// failed results.
+func resolveUpdateTargets(ctx context.Context, mgr *manager.Manager, args []string) ([]updateTarget, []updateResult) {
+	targets := make([]updateTarget, 0, len(args))
+	failures := make([]updateResult, 0)
+
+	for _, arg := range args {
+		spec, err := parseSpecArg(arg, false)
+		if err == nil {
+			var pm manager.PackageManager
+			pm, err = resolveInstalled(ctx, mgr, spec, "Update it with")
+			if err == nil {
+				targets = append(targets, updateTarget{pm: pm, name: spec.Name})
+				continue
+			}
+		}
+		failures = append(failures, updateResult{provider: spec.Provider, target: arg, err: err})
+	}
+
+	return targets, failures
+}
+
+// updatePackages updates each resolved package with its package manager,
+// one at a time. It stops when interrupted or an update times out, returning
+// the results so far along with the error.
+func updatePackages(ctx context.Context, targets []updateTarget) ([]updateResult, error) {
+	results := make([]updateResult, 0, len(targets))
+	for i, t := range targets {
+		message := fmt.Sprintf("[%d/%d] Updating %s with %s...", i+1, len(targets), t.name, t.pm.GetName())
+		err := runWithSpinner(ctx, message, func(ctx context.Context) error {
+			return t.pm.Update(ctx, t.name)
+		})
+		if ctx.Err() != nil || isInterrupted(err) {
+			return results, err
+		}
+		results = append(results, updateResult{provider: t.pm.GetName(), target: t.name, err: err})
+	}
+	return results, nil
+}
+
+func renderUpdateSummary(results []updateResult) string {
+	rows := make([][]string, 0, len(results))
+	for _, r := range results {
+		details := ""
+		switch {
+		case r.skipped:
+			details = "package manager not available"
+		case r.err != nil:
+			details = firstLine(r.err.Error())
+		}
+		rows = append(rows, []string{r.provider, r.target, r.status(), details})
+	}
+
+	return renderTable([]column{
+		{header: "Provider", style: providerStyle},
+		{header: "Target", style: titleStyle},
+		{header: "Status", rowStyle: func(row int) lipgloss.Style { return statusStyle(results[row]) }},
+		{header: "Details", style: descStyle, overflow: overflowWrap},
+	}, rows)
+}
+
+func statusStyle(r updateResult) lipgloss.Style {
+	switch {
+	case r.skipped:
+		return mutedStyle
+	case r.err != nil:
+		return errorStyle
+	default:
+		return successStyle
+	}
+}
+
+// runUpdate updates the named packages, or every package with "all" or no
+// arguments, and prints a summary. A package that is ambiguous when PPM
+// cannot prompt stops the command before anything is updated.
+func runUpdate(ctx context.Context, mgr *manager.Manager, args []string) error {
+	var (
+		results []updateResult
+		err     error
+	)
+	if len(args) == 0 || (len(args) == 1 && args[0] == "all") {
+		err = runWithSpinner(ctx, "Updating all packages across package managers...", func(ctx context.Context) error {
+			results = updateAll(ctx, mgr)
+			return ctx.Err()
+		})
+	} else {
+		targets, failures := resolveUpdateTargets(ctx, mgr, args)
+		if ctx.Err() != nil {
+			return interruptedError(ctx, ctx.Err())
+		}
+		for _, f := range failures {
+			if ExitCode(f.err) == ExitInputRequired {
+				return f.err
+			}
+		}
+		results, err = updatePackages(ctx, targets)
+		if err == nil {
+			results = append(results, failures...)
+		}
+	}
+	if len(results) > 0 {
+		fmt.Println()
+		fmt.Print(renderUpdateSummary(results))
+	}
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, r := range results {
+		if r.err != nil {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d updates failed", failed, len(results))
+	}
+
+	fmt.Printf("\n✓ Update complete\n")
+	return nil
+}
+
+func NewUpdateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "update [package...|all]",
+		Short: "Update packages, or everything across all package managers",
+		Long: `Update one or more packages using the appropriate package manager.
+
+Packages are updated with the package manager that has them installed. When
+several do, you are asked which one to use unless the package is prefixed
+with a package manager, e.g. "ppm update npm:typescript". When PPM cannot
+ask, an ambiguous package is an error and nothing is updated.
+
+Running "ppm update all" (or "ppm update" without arguments) updates every
+package of every available package manager and prints a summary per manager.
+The command exits with a non-zero status if any update failed.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := operationContext(cmd)
+			defer cancel()
+
+			return runUpdate(ctx, newManager(), args)
+		},
+	}
+
+	return cmd
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/RichestHumanAlive/ppm_cli/pkg/manager"
+)
+
+// failing makes every change of a stubManager fail
+func failing(ctx context.Context) error {
+	return errors.New("exited with status 1")
+}
+
+// stubManagers registers the given stubManagers with a new Manager
+func stubManagers(pms ...*stubManager) *manager.Manager {
+	mgr := manager.New()
+	for _, pm := range pms {
+		mgr.RegisterManager(pm)
+	}
+	return mgr
+}
+
+func TestUpdatePackagesSummary(t *testing.T) {
+	npm := &stubManager{name: "npm"}
+	pip := &stubManager{name: "pip", change: failing}
+
+	results, err := updatePackages(stubCommandContext(t), []updateTarget{{pm: npm, name: "typescript"}, {pm: pip, name: "black"}})
+	if err != nil {
+		t.Fatalf("updatePackages() error = %v", err)
+	}
+	if len(results) != 2 || results[0].status() != "updated" || results[1].status() != "failed" {
+		t.Fatalf("updatePackages() = %+v, want typescript updated and black failed", results)
+	}
+
+	summary := renderUpdateSummary(results)
+	for _, want := range []string{"typescript", "updated", "black", "failed", "exited with status 1"} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("summary does not mention %q:\n%s", want, summary)
+		}
+	}
+}
+
+func TestRunUpdatePartialFailure(t *testing.T) {
+	npm := &stubManager{name: "npm", installed: []string{"typescript"}}
+	pip := &stubManager{name: "pip", installed: []string{"black"}, change: failing}
+
+	err := runUpdate(stubCommandContext(t), stubManagers(npm, pip), []string{"typescript", "black", "left-pad"})
+	if err == nil || err.Error() != "2 of 3 updates failed" || ExitCode(err) != ExitFailure {
+		t.Errorf("runUpdate() = %v, want 2 of 3 updates failed", err)
+	}
+	if len(npm.changed) != 1 || len(pip.changed) != 1 {
+		t.Errorf("updated %v with npm and %v with pip, want typescript and black", npm.changed, pip.changed)
+	}
+}
+
+func TestRunUpdateAmbiguous(t *testing.T) {
+	npm := &stubManager{name: "npm", installed: []string{"prettier", "typescript"}}
+	pip := &stubManager{name: "pip", installed: []string{"prettier"}}
+
+	err := runUpdate(stubCommandContext(t), stubManagers(npm, pip), []string{"typescript", "prettier"})
+	if ExitCode(err) != ExitInputRequired {
+		t.Errorf("runUpdate() = %v, want an input required error", err)
+	}
+	if len(npm.changed) != 0 || len(pip.changed) != 0 {
+		t.Errorf("updated %v with npm and %v with pip, want nothing", npm.changed, pip.changed)
+	}
+
+	// A prefix picks the package manager
+	if err := runUpdate(stubCommandContext(t), stubManagers(npm, pip), []string{"pip:prettier"}); err != nil {
+		t.Errorf("runUpdate(pip:prettier) = %v", err)
+	}
+	if len(npm.changed) != 0 || len(pip.changed) != 1 {
+		t.Errorf("updated %v with npm and %v with pip, want prettier with pip", npm.changed, pip.changed)
+	}
+}
+
+func TestRunUpdateAll(t *testing.T) {
+	npm := &stubManager{name: "npm"}
+	pip := &stubManager{name: "pip", change: failing}
+
+	err := runUpdate(stubCommandContext(t), stubManagers(npm, pip), nil)
+	if err == nil || err.Error() != "1 of 2 updates failed" {
+		t.Errorf("runUpdate() = %v, want 1 of 2 updates failed", err)
+	}
+	if len(npm.changed) != 1 || npm.changed[0] != "" || len(pip.changed) != 1 {
+		t.Errorf("updated %q with npm and %q with pip, want everything with both", npm.changed, pip.changed)
+	}
+}
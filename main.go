@@ -1,33 +1,84 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/RichestHumanAlive/ppm_cli/cmd"
 	"github.com/spf13/cobra"
 )
 
 func main() {
-	if err := execute(); err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
-	}
+	os.Exit(execute())
 }
 
-func execute() error {
+func execute() int {
 	rootCmd := &cobra.Command{
 		Use:   "ppm",
 		Short: "Panoramic Package Manager - A unified package management CLI",
 		Long: `PPM is a platform-agnostic CLI tool designed to unify the workflows of multiple package managers.
-It supports npm, pip, and scoop, providing a consistent interface for managing packages across different ecosystems.`,
+It supports npm, pip, and scoop, providing a consistent interface for managing packages across different ecosystems.
+
+Exit status:
+  0    success
+  1    an operation failed, or a check found problems
+  2    invalid command line
+  3    input was needed but PPM could not prompt (see --yes)
+  124  an operation timed out (see --timeout)
+  130  interrupted`,
+		// Running the root command itself shows the help; anything else is
+		// an unknown command, reported as a usage error
+		Args: cobra.NoArgs,
+		RunE: func(c *cobra.Command, args []string) error {
+			return c.Help()
+		},
+		// Errors are printed by execute, with usage hints only for usage errors
+		SilenceErrors: true,
+		SilenceUsage:  true,
 	}
 
+	rootCmd.PersistentFlags().Duration("timeout", 30*time.Minute, "abort package manager operations that take longer than this (0 disables)")
+	cmd.AddPromptFlags(rootCmd)
+	rootCmd.PersistentFlags().String("output", "table", "output format of search, list, outdated and info: table, json, yaml or csv")
+
 	// Add commands
 	rootCmd.AddCommand(
 		cmd.NewInstallCmd(),
 		cmd.NewSearchCmd(),
+		cmd.NewInfoCmd(),
+		cmd.NewUpdateCmd(),
+		cmd.NewRemoveCmd(),
+		cmd.NewListCmd(),
+		cmd.NewOutdatedCmd(),
+		cmd.NewExportCmd(),
+		cmd.NewImportCmd(),
+		cmd.NewLockCmd(),
+		cmd.NewSyncCmd(),
 	)
 
-	return rootCmd.Execute()
+	// Cancel running package manager operations on Ctrl-C or termination
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	cmd.MarkUsageErrors(rootCmd)
+
+	c, err := rootCmd.ExecuteContextC(ctx)
+	if err == nil {
+		return cmd.ExitOK
+	}
+
+	fmt.Fprintln(os.Stderr, "Error:", err)
+	code := cmd.ExitCode(err)
+	if code == cmd.ExitUsage {
+		fmt.Fprintf(os.Stderr, "Run '%s --help' for usage.\n", c.CommandPath())
+	}
+	if ctx.Err() != nil {
+		// Interrupted while the failing step reported something else
+		code = cmd.ExitInterrupted
+	}
+	return code
 }
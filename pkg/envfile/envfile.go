@@ -0,0 +1,158 @@
+// Package envfile reads and writes ppm-env files: portable, reviewable
+// snapshots of the packages installed across package managers.
+package envfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SchemaVersion is the version of the file format written by this package.
+// Files with a newer version are rejected instead of being misread.
+const SchemaVersion = 1
+
+// DefaultPath is the file name used when none is given
+const DefaultPath = "ppm-env.yaml"
+
+// File is a snapshot of an environment
+type File struct {
+	Version  int                  `yaml:"version" json:"version"`
+	Metadata Metadata             `yaml:"metadata" json:"metadata"`
+	Packages map[string][]Package `yaml:"packages" json:"packages"` // Installed packages by package manager
+}
+
+// Metadata describes the machine the snapshot was taken on
+type Metadata struct {
+	OS       string            `yaml:"os" json:"os"`             // Operating system, as in GOOS
+	Arch     string            `yaml:"arch" json:"arch"`         // CPU architecture, as in GOARCH
+	Managers map[string]string `yaml:"managers" json:"managers"` // Package manager versions by name
+}
+
+// Package is an installed package
+type Package struct {
+	Name    string `yaml:"name" json:"name"`
+	Version string `yaml:"version" json:"version"`
+	Scope   string `yaml:"scope,omitempty" json:"scope,omitempty"`   // global, user or site
+	Source  string `yaml:"source,omitempty" json:"source,omitempty"` // Registry, bucket or local path
+}
+
+// Format is an encoding of the file
+type Format string
+
+const (
+	YAML Format = "yaml"
+	JSON Format = "json"
+)
+
+// FormatForPath picks the format from a file extension, defaulting to YAML
+func FormatForPath(path string) Format {
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return JSON
+	}
+	return YAML
+}
+
+// New returns an empty snapshot for the given platform
+func New(goos, goarch string) *File {
+	return &File{
+		Version:  SchemaVersion,
+		Metadata: Metadata{OS: goos, Arch: goarch, Managers: make(map[string]string)},
+		Packages: make(map[string][]Package),
+	}
+}
+
+// Sort orders every provider's packages by name so that files diff cleanly
+func (f *File) Sort() {
+	for _, pkgs := range f.Packages {
+		sort.Slice(pkgs, func(i, j int) bool {
+			return strings.ToLower(pkgs[i].Name) < strings.ToLower(pkgs[j].Name)
+		})
+	}
+}
+
+// Count returns the number of packages across all providers
+func (f *File) Count() int {
+	n := 0
+	for _, pkgs := range f.Packages {
+		n += len(pkgs)
+	}
+	return n
+}
+
+// Providers returns the package manager names in the file, sorted
+func (f *File) Providers() []string {
+	names := make([]string, 0, len(f.Packages))
+	for name := range f.Packages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Encode writes the file in the given format. Map keys are sorted in both
+// formats, so encoding the same environment twice gives identical output.
+func Encode(w io.Writer, f *File, format Format) error {
+	switch format {
+	case JSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(f)
+	case YAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(f); err != nil {
+			return err
+		}
+		return enc.Close()
+	default:
+		return fmt.Errorf("unsupported format %q (expected yaml or json)", format)
+	}
+}
+
+// Decode parses a file in either format, since JSON is valid YAML
+func Decode(data []byte) (*File, error) {
+	var f File
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("invalid environment file: %v", err)
+	}
+
+	switch {
+	case f.Version == 0:
+		return nil, fmt.Errorf("invalid environment file: missing version")
+	case f.Version > SchemaVersion:
+		return nil, fmt.Errorf("environment file version %d is newer than supported (%d); upgrade ppm", f.Version, SchemaVersion)
+	}
+
+	if f.Packages == nil {
+		f.Packages = make(map[string][]Package)
+	}
+	for provider, pkgs := range f.Packages {
+		for i, pkg := range pkgs {
+			if pkg.Name == "" {
+				return nil, fmt.Errorf("invalid environment file: %s package %d has no name", provider, i+1)
+			}
+		}
+	}
+
+	return &f, nil
+}
+
+// Load reads and parses the file at path
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := Decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return f, nil
+}
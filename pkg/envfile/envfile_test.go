@@ -0,0 +1,156 @@
+package envfile
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/RichestHumanAlive/ppm_cli/pkg/manager"
+)
+
+func sample() *File {
+	f := New("linux", "amd64")
+	f.Metadata.Managers["npm"] = "10.5.2"
+	f.Metadata.Managers["pip"] = "24.0"
+	f.Packages["pip"] = []Package{
+		{Name: "requests", Version: "2.31.0", Scope: "site", Source: "pypi"},
+		{Name: "black", Version: "24.4.2", Scope: "user", Source: "pypi"},
+	}
+	f.Packages["npm"] = []Package{{Name: "typescript", Version: "5.4.5", Scope: "global", Source: "registry"}}
+	f.Sort()
+	return f
+}
+
+func TestEncodeYAML(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Encode(&buf, sample(), YAML); err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	want := `version: 1
+metadata:
+  os: linux
+  arch: amd64
+  managers:
+    npm: 10.5.2
+    pip: "24.0"
+packages:
+  npm:
+    - name: typescript
+      version: 5.4.5
+      scope: global
+      source: registry
+  pip:
+    - name: black
+      version: 24.4.2
+      scope: user
+      source: pypi
+    - name: requests
+      version: 2.31.0
+      scope: site
+      source: pypi
+`
+	if got := buf.String(); got != want {
+		t.Errorf("Encode() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, format := range []Format{YAML, JSON} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Encode(&buf, sample(), format); err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+
+			f, err := Decode(buf.Bytes())
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if f.Count() != 3 || f.Metadata.Managers["pip"] != "24.0" || f.Metadata.OS != "linux" {
+				t.Errorf("Decode() = %+v", f)
+			}
+			if pkg := f.Packages["pip"][1]; pkg.Name != "requests" || pkg.Version != "2.31.0" || pkg.Scope != "site" {
+				t.Errorf("Decode() pip package = %+v", pkg)
+			}
+			if got := f.Providers(); strings.Join(got, ",") != "npm,pip" {
+				t.Errorf("Providers() = %v", got)
+			}
+		})
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := map[string]string{
+		"not yaml":        "version: [",
+		"missing version": "packages: {}",
+		"newer version":   "version: 99\npackages: {}",
+		"missing name":    "version: 1\npackages:\n  npm:\n    - version: 1.0.0",
+	}
+
+	for name, input := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Decode([]byte(input)); err == nil {
+				t.Error("Decode() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestFormatForPath(t *testing.T) {
+	tests := map[string]Format{
+		"ppm-env.yaml": YAML,
+		"env.yml":      YAML,
+		"env.JSON":     JSON,
+		"-":            YAML,
+	}
+	for path, want := range tests {
+		if got := FormatForPath(path); got != want {
+			t.Errorf("FormatForPath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestPlan(t *testing.T) {
+	want := []Package{
+		{Name: "requests", Version: "2.31.0"},
+		{Name: "black", Version: "24.4.2"},
+		{Name: "Flask", Version: "3.0.0"},
+		{Name: "typing_extensions", Version: "4.11.0"},
+		{Name: "httpx", Version: "0.27.0"},
+	}
+	installed := []manager.Package{
+		{Name: "requests", Version: "2.31.0"},
+		{Name: "black", Version: "23.1.0"},
+		{Name: "flask", Version: "3.0.3"},
+		{Name: "typing-extensions", Version: "4.11.0"},
+	}
+
+	steps := Plan("pip", want, installed)
+
+	wantActions := []Action{Skip, Upgrade, Skip, Skip, Install}
+	if len(steps) != len(wantActions) {
+		t.Fatalf("Plan() = %+v", steps)
+	}
+	for i, action := range wantActions {
+		if steps[i].Action != action {
+			t.Errorf("Plan()[%d] (%s) action = %s, want %s", i, steps[i].Name, steps[i].Action, action)
+		}
+	}
+	if steps[1].Installed != "23.1.0" || steps[1].Target != "24.4.2" || !steps[1].Pending() {
+		t.Errorf("Plan()[1] = %+v", steps[1])
+	}
+	if steps[2].Reason != "newer version installed" {
+		t.Errorf("Plan()[2].Reason = %q", steps[2].Reason)
+	}
+	if steps[4].Installed != "" || steps[4].Provider != "pip" {
+		t.Errorf("Plan()[4] = %+v", steps[4])
+	}
+}
+
+func TestBlocked(t *testing.T) {
+	steps := Blocked("scoop", []Package{{Name: "git", Version: "2.45.1"}}, Unsupported, "scoop does not run on linux")
+	if len(steps) != 1 || steps[0].Action != Unsupported || steps[0].Pending() || steps[0].Target != "2.45.1" {
+		t.Errorf("Blocked() = %+v", steps)
+	}
+}
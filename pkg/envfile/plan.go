@@ -0,0 +1,91 @@
+package envfile
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/RichestHumanAlive/ppm_cli/pkg/manager"
+)
+
+// Action is what importing a file does with one package
+type Action string
+
+const (
+	Install     Action = "install"     // Not installed yet
+	Upgrade     Action = "upgrade"     // Installed with an older version
+	Skip        Action = "skip"        // Already installed at the recorded version or newer
+	Unsupported Action = "unsupported" // The package manager does not run on this OS
+	Unavailable Action = "unavailable" // The package manager is not installed on this host
+)
+
+// Step is the planned action for one package of the file
+type Step struct {
+	Provider  string
+	Name      string
+	Installed string // Installed version, empty if not installed
+	Target    string // Version recorded in the file
+	Action    Action
+	Reason    string // Why the step is skipped or cannot run, if it is
+}
+
+// Plan compares the packages a file records for one package manager with the
+// packages it has installed and returns the steps that bring the host in line
+// with the file.
+// Names match when manager.SameName says so for provider.
+func Plan(provider string, want []Package, installed []manager.Package) []Step {
+	steps := make([]Step, 0, len(want))
+	for _, pkg := range want {
+		step := Step{Provider: provider, Name: pkg.Name, Target: pkg.Version}
+
+		var current *manager.Package
+		for i := range installed {
+			if manager.SameName(provider, installed[i].Name, pkg.Name) {
+				current = &installed[i]
+				break
+			}
+		}
+
+		switch {
+		case current == nil:
+			step.Action = Install
+		case pkg.Version == "" || manager.CompareVersions(current.Version, pkg.Version) == 0:
+			step.Installed = current.Version
+			step.Action = Skip
+			step.Reason = "already installed"
+		case manager.CompareVersions(current.Version, pkg.Version) > 0:
+			step.Installed = current.Version
+			step.Action = Skip
+			step.Reason = "newer version installed"
+		default:
+			step.Installed = current.Version
+			step.Action = Upgrade
+		}
+		steps = append(steps, step)
+	}
+	return steps
+}
+
+// Blocked returns steps for packages whose package manager cannot be used
+// on this host, with the given action and reason
+func Blocked(provider string, want []Package, action Action, reason string) []Step {
+	steps := make([]Step, 0, len(want))
+	for _, pkg := range want {
+		steps = append(steps, Step{Provider: provider, Name: pkg.Name, Target: pkg.Version, Action: action, Reason: reason})
+	}
+	return steps
+}
+
+// SortSteps orders steps by provider and package name
+func SortSteps(steps []Step) {
+	sort.SliceStable(steps, func(i, j int) bool {
+		if steps[i].Provider != steps[j].Provider {
+			return steps[i].Provider < steps[j].Provider
+		}
+		return strings.ToLower(steps[i].Name) < strings.ToLower(steps[j].Name)
+	})
+}
+
+// Pending reports whether the step changes the host when applied
+func (s Step) Pending() bool {
+	return s.Action == Install || s.Action == Upgrade
+}
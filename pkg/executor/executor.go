@@ -0,0 +1,77 @@
+// Package executor runs the external commands behind the package manager
+// backends. Backends depend on the Executor interface so tests can replace
+// the real binaries with scripted output.
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"time"
+)
+
+// Executor runs external commands. Cancelling the context kills the command.
+type Executor interface {
+	// Run runs the command and only reports whether it succeeded
+	Run(ctx context.Context, name string, args ...string) error
+
+	// Output runs the command and returns its standard output
+	Output(ctx context.Context, name string, args ...string) ([]byte, error)
+
+	// CombinedOutput runs the command and returns its standard output
+	// followed by its standard error
+	CombinedOutput(ctx context.Context, name string, args ...string) ([]byte, error)
+}
+
+// ExitError reports a command that ran but exited with a non-zero status
+type ExitError struct {
+	Code   int    // Exit status of the command
+	Stderr []byte // Standard error, when it was not already returned as output
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// waitDelay bounds how long a killed command may keep its output pipes open,
+// e.g. through child processes of a wrapper script like npm.cmd
+const waitDelay = 2 * time.Second
+
+// System is the Executor that runs real binaries found in PATH
+type System struct{}
+
+func command(ctx context.Context, name string, args []string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.WaitDelay = waitDelay
+	return cmd
+}
+
+func (System) Run(ctx context.Context, name string, args ...string) error {
+	return wrap(ctx, command(ctx, name, args).Run())
+}
+
+func (System) Output(ctx context.Context, name string, args ...string) ([]byte, error) {
+	output, err := command(ctx, name, args).Output()
+	return output, wrap(ctx, err)
+}
+
+func (System) CombinedOutput(ctx context.Context, name string, args ...string) ([]byte, error) {
+	output, err := command(ctx, name, args).CombinedOutput()
+	return output, wrap(ctx, err)
+}
+
+// wrap converts *exec.ExitError into *ExitError so callers and fakes report
+// failures the same way. A command killed because ctx ended reports the
+// context error instead. Other errors, such as a missing binary, pass through.
+func wrap(ctx context.Context, err error) error {
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return &ExitError{Code: exitErr.ExitCode(), Stderr: exitErr.Stderr}
+	}
+	return err
+}
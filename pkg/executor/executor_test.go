@@ -0,0 +1,54 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestSystemExitError(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	output, err := System{}.Output(context.Background(), "sh", "-c", "echo out; echo err >&2; exit 3")
+
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) {
+		t.Fatalf("Output() error = %v, want *ExitError", err)
+	}
+	if exitErr.Code != 3 {
+		t.Errorf("ExitError.Code = %d, want 3", exitErr.Code)
+	}
+	if string(exitErr.Stderr) != "err\n" {
+		t.Errorf("ExitError.Stderr = %q, want %q", exitErr.Stderr, "err\n")
+	}
+	if string(output) != "out\n" {
+		t.Errorf("Output() = %q, want %q", output, "out\n")
+	}
+}
+
+func TestSystemMissingBinary(t *testing.T) {
+	err := System{}.Run(context.Background(), "ppm-definitely-not-a-real-binary")
+
+	var exitErr *ExitError
+	if err == nil || errors.As(err, &exitErr) {
+		t.Errorf("Run() error = %v, want a lookup error", err)
+	}
+}
+
+func TestSystemCancelled(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := System{}.Run(ctx, "sh", "-c", "sleep 5")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Run() error = %v, want context.DeadlineExceeded", err)
+	}
+}
@@ -0,0 +1,121 @@
+// Package executortest provides a scripted executor.Executor for testing
+// package manager backends without the real binaries.
+package executortest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/RichestHumanAlive/ppm_cli/pkg/executor"
+)
+
+// Response is the recorded result of a single command
+type Response struct {
+	Stdout   string // Standard output
+	Stderr   string // Standard error
+	ExitCode int    // Exit status; non-zero makes the call return *executor.ExitError
+	Err      error  // Error returned instead of running, e.g. a missing binary
+}
+
+// Fake is an executor.Executor that replays scripted responses. Commands are
+// matched on their full command line; unscripted commands fail.
+type Fake struct {
+	mu        sync.Mutex
+	responses map[string][]Response
+	calls     []string
+}
+
+// New returns a Fake with no scripted commands
+func New() *Fake {
+	return &Fake{responses: make(map[string][]Response)}
+}
+
+// On scripts the response for a command line such as "npm install -g lodash".
+// Scripting the same command several times replays the responses in order;
+// the last one is repeated once the others are used up.
+func (f *Fake) On(cmdline string, resp Response) *Fake {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.responses[cmdline] = append(f.responses[cmdline], resp)
+	return f
+}
+
+// Calls returns the command lines that were run, in order
+func (f *Fake) Calls() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.calls...)
+}
+
+// Called reports whether cmdline was run at least once
+func (f *Fake) Called(cmdline string) bool {
+	for _, call := range f.Calls() {
+		if call == cmdline {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *Fake) next(ctx context.Context, name string, args []string) (Response, error) {
+	cmdline := strings.Join(append([]string{name}, args...), " ")
+
+	if err := ctx.Err(); err != nil {
+		return Response{}, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls = append(f.calls, cmdline)
+
+	queue, ok := f.responses[cmdline]
+	if !ok || len(queue) == 0 {
+		return Response{}, fmt.Errorf("executortest: unexpected command %q", cmdline)
+	}
+	resp := queue[0]
+	if len(queue) > 1 {
+		f.responses[cmdline] = queue[1:]
+	}
+	return resp, nil
+}
+
+func (r Response) err(stderrReturned bool) error {
+	if r.Err != nil {
+		return r.Err
+	}
+	if r.ExitCode == 0 {
+		return nil
+	}
+	exitErr := &executor.ExitError{Code: r.ExitCode}
+	if !stderrReturned {
+		exitErr.Stderr = []byte(r.Stderr)
+	}
+	return exitErr
+}
+
+func (f *Fake) Run(ctx context.Context, name string, args ...string) error {
+	resp, err := f.next(ctx, name, args)
+	if err != nil {
+		return err
+	}
+	return resp.err(false)
+}
+
+func (f *Fake) Output(ctx context.Context, name string, args ...string) ([]byte, error) {
+	resp, err := f.next(ctx, name, args)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(resp.Stdout), resp.err(false)
+}
+
+func (f *Fake) CombinedOutput(ctx context.Context, name string, args ...string) ([]byte, error) {
+	resp, err := f.next(ctx, name, args)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(resp.Stdout + resp.Stderr), resp.err(true)
+}
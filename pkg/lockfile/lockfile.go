@@ -0,0 +1,160 @@
+// Package lockfile reads and writes ppm.lock, which pins every declared
+// package to an exact version and the artifact hashes its registry publishes.
+// The hashes are recorded for review; PPM does not verify them on install.
+package lockfile
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/RichestHumanAlive/ppm_cli/pkg/manager"
+	"gopkg.in/yaml.v3"
+)
+
+// SchemaVersion is the version of the lockfile format written by this package
+const SchemaVersion = 1
+
+// DefaultPath is the lockfile looked for in the working directory
+const DefaultPath = "ppm.lock"
+
+// File is a lockfile
+type File struct {
+	Version  int                  `yaml:"version"`
+	Packages map[string][]Package `yaml:"packages"` // Locked packages by package manager
+}
+
+// Package is a package pinned to an exact version
+type Package struct {
+	Name    string   `yaml:"name"`
+	Version string   `yaml:"version"`
+	Hashes  []string `yaml:"hashes,omitempty,flow"`
+}
+
+// New returns an empty lockfile
+func New() *File {
+	return &File{Version: SchemaVersion, Packages: make(map[string][]Package)}
+}
+
+// Add locks a resolved package for a package manager
+func (f *File) Add(provider string, pkg manager.ResolvedPackage) {
+	f.Packages[provider] = append(f.Packages[provider], Package{Name: pkg.Name, Version: pkg.Version, Hashes: pkg.Hashes})
+}
+
+// Find returns the locked package for a package manager and name
+func (f *File) Find(provider, name string) (Package, bool) {
+	for _, pkg := range f.Packages[provider] {
+		if manager.SameName(provider, pkg.Name, name) {
+			return pkg, true
+		}
+	}
+	return Package{}, false
+}
+
+// Providers returns the package managers that lock a package with the given
+// name, sorted
+func (f *File) Providers(name string) []string {
+	providers := make([]string, 0)
+	for provider := range f.Packages {
+		if _, ok := f.Find(provider, name); ok {
+			providers = append(providers, provider)
+		}
+	}
+	sort.Strings(providers)
+	return providers
+}
+
+// Count returns the number of locked packages
+func (f *File) Count() int {
+	n := 0
+	for _, pkgs := range f.Packages {
+		n += len(pkgs)
+	}
+	return n
+}
+
+// Encode writes the lockfile with packages sorted by name, so relocking an
+// unchanged set of packages gives identical output
+func Encode(w io.Writer, f *File) error {
+	for _, pkgs := range f.Packages {
+		sort.Slice(pkgs, func(i, j int) bool {
+			return strings.ToLower(pkgs[i].Name) < strings.ToLower(pkgs[j].Name)
+		})
+	}
+
+	if _, err := fmt.Fprintln(w, "# Generated by \"ppm lock\". Do not edit by hand."); err != nil {
+		return err
+	}
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(f); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// Decode parses a lockfile
+func Decode(data []byte) (*File, error) {
+	var f File
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("invalid lockfile: %v", err)
+	}
+
+	switch {
+	case f.Version == 0:
+		return nil, fmt.Errorf("invalid lockfile: missing version")
+	case f.Version > SchemaVersion:
+		return nil, fmt.Errorf("lockfile version %d is newer than supported (%d); upgrade ppm", f.Version, SchemaVersion)
+	}
+
+	if f.Packages == nil {
+		f.Packages = make(map[string][]Package)
+	}
+	for provider, pkgs := range f.Packages {
+		for i, pkg := range pkgs {
+			if pkg.Name == "" || pkg.Version == "" {
+				return nil, fmt.Errorf("invalid lockfile: %s package %d needs a name and a version", provider, i+1)
+			}
+		}
+	}
+
+	return &f, nil
+}
+
+// Load reads the lockfile at path
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := Decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return f, nil
+}
+
+// LoadIfExists reads the lockfile at path, returning nil without an error
+// when there is none
+func LoadIfExists(path string) (*File, error) {
+	f, err := Load(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return f, err
+}
+
+// Save writes the lockfile to path
+func Save(path string, f *File) error {
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := Encode(out, f); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,97 @@
+package lockfile
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/RichestHumanAlive/ppm_cli/pkg/manager"
+)
+
+func TestEncode(t *testing.T) {
+	f := New()
+	f.Add("pip", manager.ResolvedPackage{Name: "requests", Version: "2.31.0", Hashes: []string{"sha256:58cd", "sha256:942c"}})
+	f.Add("npm", manager.ResolvedPackage{Name: "typescript", Version: "5.4.5", Hashes: []string{"sha512-abc=="}})
+	f.Add("npm", manager.ResolvedPackage{Name: "lodash", Version: "4.17.21"})
+
+	var buf bytes.Buffer
+	if err := Encode(&buf, f); err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	want := `# Generated by "ppm lock". Do not edit by hand.
+version: 1
+packages:
+  npm:
+    - name: lodash
+      version: 4.17.21
+    - name: typescript
+      version: 5.4.5
+      hashes: [sha512-abc==]
+  pip:
+    - name: requests
+      version: 2.31.0
+      hashes: ['sha256:58cd', 'sha256:942c']
+`
+	if got := buf.String(); got != want {
+		t.Errorf("Encode() =\n%s\nwant\n%s", got, want)
+	}
+
+	decoded, err := Decode(buf.Bytes())
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if pkg, ok := decoded.Find("pip", "Requests"); !ok || pkg.Version != "2.31.0" || len(pkg.Hashes) != 2 {
+		t.Errorf("Find(pip, Requests) = %+v, %v", pkg, ok)
+	}
+	if decoded.Count() != 3 {
+		t.Errorf("Count() = %d, want 3", decoded.Count())
+	}
+}
+
+func TestProviders(t *testing.T) {
+	f := New()
+	f.Add("pip", manager.ResolvedPackage{Name: "black", Version: "24.4.2"})
+	f.Add("npm", manager.ResolvedPackage{Name: "black", Version: "0.3.0"})
+	f.Add("npm", manager.ResolvedPackage{Name: "lodash", Version: "4.17.21"})
+
+	if got := f.Providers("black"); len(got) != 2 || got[0] != "npm" || got[1] != "pip" {
+		t.Errorf("Providers(black) = %v", got)
+	}
+	if got := f.Providers("missing"); len(got) != 0 {
+		t.Errorf("Providers(missing) = %v", got)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := map[string]string{
+		"not yaml":        "packages: [",
+		"missing version": "packages: {}",
+		"newer version":   "version: 2",
+		"unpinned":        "version: 1\npackages:\n  npm:\n    - name: lodash",
+	}
+
+	for name, input := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Decode([]byte(input)); err == nil {
+				t.Error("Decode() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestLoadIfExists(t *testing.T) {
+	dir := t.TempDir()
+
+	f, err := LoadIfExists(filepath.Join(dir, DefaultPath))
+	if f != nil || err != nil {
+		t.Errorf("LoadIfExists(missing) = %v, %v, want nil, nil", f, err)
+	}
+
+	path := filepath.Join(dir, DefaultPath)
+	os.WriteFile(path, []byte("version: 1\npackages:\n  npm:\n    - name: lodash\n      version: 4.17.21\n"), 0o644)
+	if f, err := LoadIfExists(path); err != nil || f.Count() != 1 {
+		t.Errorf("LoadIfExists() = %v, %v", f, err)
+	}
+}
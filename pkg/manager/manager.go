@@ -1,49 +1,243 @@
 package manager
 
-// PackageManager defines the interface that all package managers must implement
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PackageManager defines the interface that all package managers must implement.
+// Every operation that talks to the underlying tool takes a context; cancelling
+// it stops the running tool.
 type PackageManager interface {
-	// Install installs a package
-	Install(pkg string) error
-	
+	// Install installs a package, translating the spec's version constraint to
+	// the package manager's syntax. Constraints the package manager cannot
+	// express are rejected with an error.
+	Install(ctx context.Context, spec PackageSpec) error
+
 	// Search searches for a package
-	Search(query string) ([]Package, error)
-	
+	Search(ctx context.Context, query string) ([]Package, error)
+
 	// Update updates a package or all packages if pkg is empty
-	Update(pkg string) error
-	
+	Update(ctx context.Context, pkg string) error
+
 	// Remove removes a package
-	Remove(pkg string) error
-	
+	Remove(ctx context.Context, pkg string) error
+
+	// ListInstalled returns the packages currently installed by this package manager
+	ListInstalled(ctx context.Context) ([]Package, error)
+
+	// ListOutdated returns installed packages that have a newer version available
+	ListOutdated(ctx context.Context) ([]OutdatedPackage, error)
+
+	// IsInstalled checks if a package is currently installed by this package manager
+	IsInstalled(ctx context.Context, pkg string) bool
+
 	// IsAvailable checks if this package manager is available on the system
-	IsAvailable() bool
-	
+	IsAvailable(ctx context.Context) bool
+
+	// Version returns the version of the package manager itself
+	Version(ctx context.Context) (string, error)
+
 	// GetName returns the name of the package manager
 	GetName() string
 }
 
-// Package represents a package in any package manager
+// OSRestricted is implemented by package managers that only run on some
+// operating systems, e.g. scoop on Windows
+type OSRestricted interface {
+	// SupportsOS reports whether the package manager runs on goos
+	SupportsOS(goos string) bool
+}
+
+// SupportsOS reports whether pm runs on the operating system goos (a GOOS
+// value). Package managers without restrictions run everywhere.
+func SupportsOS(pm PackageManager, goos string) bool {
+	if r, ok := pm.(OSRestricted); ok {
+		return r.SupportsOS(goos)
+	}
+	return true
+}
+
+// ConcurrencyLimited is implemented by package managers whose tool cannot run
+// several operations at once, e.g. because it locks its own state
+type ConcurrencyLimited interface {
+	// MaxConcurrency returns how many operations may run at the same time
+	MaxConcurrency() int
+}
+
+// MaxConcurrency returns how many operations pm may run at the same time, or
+// 0 when it has no limit
+func MaxConcurrency(pm PackageManager) int {
+	if l, ok := pm.(ConcurrencyLimited); ok {
+		return l.MaxConcurrency()
+	}
+	return 0
+}
+
+// Resolver is implemented by package managers that can pin a package spec
+// to one published version without installing it
+type Resolver interface {
+	// Resolve returns the version a spec selects (the latest one when it has
+	// no constraint) along with the artifact hashes the registry publishes
+	Resolve(ctx context.Context, spec PackageSpec) (ResolvedPackage, error)
+}
+
+// TopLevelLister is implemented by package managers that can tell the
+// packages installed on request from the ones pulled in only as dependencies
+// of others
+type TopLevelLister interface {
+	// ListTopLevel returns the installed packages that no other installed
+	// package depends on
+	ListTopLevel(ctx context.Context) ([]Package, error)
+}
+
+// InfoProvider is implemented by package managers that can describe a package
+// in detail without installing it
+type InfoProvider interface {
+	// GetInfo returns the metadata of a package's latest version along with
+	// every published version and the latest version's dependencies. A
+	// package the package manager does not publish is a *NotFoundError.
+	GetInfo(ctx context.Context, name string) (PackageInfo, error)
+}
+
+// RegistrySearcher is implemented by package managers whose Search queries
+// the package registry directly rather than running the package manager, so
+// that searching works without it installed
+type RegistrySearcher interface {
+	// SearchesRegistry reports whether Search works without the package
+	// manager installed
+	SearchesRegistry() bool
+}
+
+// SearchesRegistry reports whether pm can search without being installed
+func SearchesRegistry(pm PackageManager) bool {
+	r, ok := pm.(RegistrySearcher)
+	return ok && r.SearchesRegistry()
+}
+
+// ExactFinder is implemented by package managers that can look a package up
+// by its exact name more cheaply than by searching for it
+type ExactFinder interface {
+	// FindPackage returns the package published under name. A package the
+	// package manager does not publish is a *NotFoundError.
+	FindPackage(ctx context.Context, name string) (Package, error)
+}
+
+// PackageInfo is the detailed description of a package returned by GetInfo
+type PackageInfo struct {
+	Package      `yaml:",inline"`
+	Releases     []Release `json:"releases" yaml:"releases"`         // Published versions, newest first
+	Dependencies []string  `json:"dependencies" yaml:"dependencies"` // Requirements of the latest version, as package specs
+	Installed    string    `json:"installed" yaml:"installed"`       // Installed version, empty when not installed; left to the caller
+}
+
+// ReleaseDateFormat is the layout of Release.Date and Package.Updated
+const ReleaseDateFormat = "2006-01-02"
+
+// Release is one published version of a package
+type Release struct {
+	Version string `json:"version" yaml:"version"`
+	Date    string `json:"date,omitempty" yaml:"date,omitempty"` // Publication date as YYYY-MM-DD, empty when unknown
+}
+
+// String returns the version followed by its date, if known
+func (r Release) String() string {
+	if r.Date == "" {
+		return r.Version
+	}
+	return r.Version + " (" + r.Date + ")"
+}
+
+// SortReleases orders releases newest version first
+func SortReleases(releases []Release) {
+	sort.SliceStable(releases, func(i, j int) bool {
+		return CompareVersions(releases[i].Version, releases[j].Version) > 0
+	})
+}
+
+// NotFoundError is returned by lookups of a package a package manager does
+// not publish, as opposed to lookups that failed
+type NotFoundError struct {
+	Provider string
+	Name     string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("no %s package named %s", e.Provider, e.Name)
+}
+
+// ResolvedPackage is a package pinned to an exact version
+type ResolvedPackage struct {
+	Name    string
+	Version string
+	Hashes  []string // Artifact hashes as "algorithm:hex" or SRI "algorithm-base64" strings
+}
+
+// Package represents a package in any package manager. The JSON and YAML
+// field names are part of ppm's machine-readable output and must stay stable.
 type Package struct {
-	Name        string  // Package name
-	Version     string  // Latest version
-	Description string  // Package description
-	Author      string  // Package author/maintainer
-	Provider    string  // Package manager (npm, pip, scoop)
-	Score      float64 // Relevance score (0-1)
-	Downloads  int64   // Number of downloads (if available)
-	Homepage   string  // Package homepage URL
-	Repository string  // Source code repository URL
+	Name        string   `json:"name" yaml:"name"`               // Package name
+	Version     string   `json:"version" yaml:"version"`         // Latest version, or the installed version for installed packages
+	Description string   `json:"description" yaml:"description"` // Package description
+	Author      string   `json:"author" yaml:"author"`           // Package author/maintainer
+	Provider    string   `json:"provider" yaml:"provider"`       // Package manager (npm, pip, scoop)
+	Score       float64  `json:"score" yaml:"score"`             // Relevance score (0-1)
+	Downloads   int64    `json:"downloads" yaml:"downloads"`     // Number of downloads (if available)
+	Homepage    string   `json:"homepage" yaml:"homepage"`       // Package homepage URL
+	Repository  string   `json:"repository" yaml:"repository"`   // Source code repository URL
+	Source      string   `json:"source" yaml:"source"`           // Where an installed package came from (registry, bucket, ...)
+	Scope       string   `json:"scope" yaml:"scope"`             // Install scope of an installed package (global, user, site)
+	License     string   `json:"license" yaml:"license"`         // SPDX license identifier (if available)
+	Keywords    []string `json:"keywords" yaml:"keywords"`       // Keywords or tags (if available)
+	Maintainers []string `json:"maintainers" yaml:"maintainers"` // Maintainer names (if available)
+	Updated     string   `json:"updated" yaml:"updated"`         // Release date of Version as ReleaseDateFormat (if available)
 }
 
+// OutdatedPackage describes an installed package with a newer version available
+type OutdatedPackage struct {
+	Name     string `json:"name" yaml:"name"`         // Package name
+	Provider string `json:"provider" yaml:"provider"` // Package manager (npm, pip, scoop)
+	Current  string `json:"current" yaml:"current"`   // Installed version
+	Wanted   string `json:"wanted" yaml:"wanted"`     // Newest version allowed by the install constraints
+	Latest   string `json:"latest" yaml:"latest"`     // Newest published version
+}
+
+// DefaultSearchTimeout bounds how long SearchAcrossAll waits for a single
+// package manager
+const DefaultSearchTimeout = 30 * time.Second
+
 // Manager handles operations across multiple package managers
 type Manager struct {
-	managers []PackageManager
+	managers      []PackageManager
+	searchTimeout time.Duration
+}
+
+// Option configures a Manager
+type Option func(*Manager)
+
+// WithSearchTimeout bounds how long SearchAcrossAll waits for a single package
+// manager; zero or less disables the limit
+func WithSearchTimeout(d time.Duration) Option {
+	return func(m *Manager) {
+		m.searchTimeout = d
+	}
 }
 
 // New creates a new package manager handler
-func New() *Manager {
-	return &Manager{
-		managers: make([]PackageManager, 0),
+func New(opts ...Option) *Manager {
+	m := &Manager{
+		managers:      make([]PackageManager, 0),
+		searchTimeout: DefaultSearchTimeout,
+	}
+	for _, opt := range opts {
+		opt(m)
 	}
+	return m
 }
 
 // RegisterManager adds a package manager to the handler
@@ -56,22 +250,277 @@ func (m *Manager) GetManagers() []PackageManager {
 	return m.managers
 }
 
-// SearchAcrossAll searches for packages across all available package managers
-func (m *Manager) SearchAcrossAll(query string) ([]Package, error) {
-	results := make([]Package, 0)
-	
+// GetManager returns the registered package manager with the given name
+func (m *Manager) GetManager(name string) (PackageManager, bool) {
 	for _, pm := range m.managers {
-		if !pm.IsAvailable() {
-			continue
+		if pm.GetName() == name {
+			return pm, true
 		}
-		
-		pkgs, err := pm.Search(query)
-		if err != nil {
-			// Log error but continue with other package managers
-			continue
+	}
+	return nil, false
+}
+
+// pep503Separators matches the separators PEP 503 folds into a single "-"
+var pep503Separators = regexp.MustCompile(`[-_.]+`)
+
+// SameName reports whether two names of a package manager's packages refer to
+// the same package. pip names are compared after PEP 503 normalization, which
+// ignores case and treats runs of "-", "_" and "." alike; npm names are case
+// sensitive and compared as is; other package managers ignore case.
+func SameName(provider, a, b string) bool {
+	switch provider {
+	case "pip":
+		fold := func(name string) string { return pep503Separators.ReplaceAllString(name, "-") }
+		return strings.EqualFold(fold(a), fold(b))
+	case "npm":
+		return a == b
+	default:
+		return strings.EqualFold(a, b)
+	}
+}
+
+// MatchScore scores how well a package name matches a search query between 0
+// and 1, the scale search results are scored on: the same name scores 1,
+// prefixes beat other substrings and shorter names beat longer ones within
+// each. Zero means the name does not contain the query. Both are compared as
+// given, so callers normalize them the way their package manager does.
+func MatchScore(query, name string) float64 {
+	if query == "" || name == "" {
+		return 0
+	}
+	closeness := float64(len(query)) / float64(len(name))
+	switch {
+	case name == query:
+		return 1
+	case strings.HasPrefix(name, query):
+		return 0.6 + 0.3*closeness
+	case strings.Contains(name, query):
+		return 0.4 + 0.2*closeness
+	}
+	return 0
+}
+
+// FindExact searches every available package manager concurrently and returns
+// the packages published under exactly the given name, one per package
+// manager, in registration order. Package managers that are ExactFinders
+// look the name up instead of searching for it. Search errors are only reported when no
+// package manager found the package.
+func (m *Manager) FindExact(ctx context.Context, name string) ([]Package, error) {
+	found := make([]*Package, len(m.managers))
+	errs := make([]error, len(m.managers))
+
+	var wg sync.WaitGroup
+	for i, pm := range m.managers {
+		wg.Add(1)
+		go func(i int, pm PackageManager) {
+			defer wg.Done()
+			if !pm.IsAvailable(ctx) {
+				return
+			}
+
+			if finder, ok := pm.(ExactFinder); ok {
+				pkg, err := finder.FindPackage(ctx, name)
+				if _, ok := err.(*NotFoundError); ok {
+					return
+				}
+				if err != nil {
+					errs[i] = fmt.Errorf("%s: %v", pm.GetName(), err)
+					return
+				}
+				found[i] = &pkg
+				return
+			}
+
+			pkgs, err := pm.Search(ctx, name)
+			if err != nil {
+				errs[i] = fmt.Errorf("%s: %v", pm.GetName(), err)
+				return
+			}
+			for _, pkg := range pkgs {
+				if SameName(pm.GetName(), pkg.Name, name) {
+					pkg := pkg
+					found[i] = &pkg
+					return
+				}
+			}
+		}(i, pm)
+	}
+	wg.Wait()
+
+	results := make([]Package, 0)
+	var firstErr error
+	for i := range m.managers {
+		if found[i] != nil {
+			results = append(results, *found[i])
 		}
-		results = append(results, pkgs...)
+		if errs[i] != nil && firstErr == nil {
+			firstErr = errs[i]
+		}
+	}
+
+	if len(results) == 0 && firstErr != nil {
+		return nil, firstErr
 	}
-	
 	return results, nil
 }
+
+// ProviderError is the failure of one package manager in an operation
+// across several of them
+type ProviderError struct {
+	Provider string
+	Err      error
+}
+
+func (e *ProviderError) Error() string {
+	return e.Provider + ": " + e.Err.Error()
+}
+
+func (e *ProviderError) Unwrap() error {
+	return e.Err
+}
+
+// MultiError collects the package managers that failed in an operation across
+// several of them, in registration order
+type MultiError struct {
+	Errors []*ProviderError
+}
+
+func (e *MultiError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Unwrap lets errors.Is and errors.As look at every package manager's error
+func (e *MultiError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// ByProvider returns the errors keyed by package manager name
+func (e *MultiError) ByProvider() map[string]error {
+	errs := make(map[string]error, len(e.Errors))
+	for _, err := range e.Errors {
+		errs[err.Provider] = err.Err
+	}
+	return errs
+}
+
+// SearchStatus is the state of one package manager during SearchStream
+type SearchStatus string
+
+const (
+	StatusSearching   SearchStatus = "searching"
+	StatusDone        SearchStatus = "done"
+	StatusFailed      SearchStatus = "failed"
+	StatusUnavailable SearchStatus = "unavailable"
+)
+
+// SearchEvent reports a package manager's progress in SearchStream
+type SearchEvent struct {
+	Provider  string
+	Status    SearchStatus
+	Packages  []Package // Results, once Status is StatusDone
+	Err       error     // Failure, once Status is StatusFailed
+	Installed bool      // Whether the package manager is installed, once Status is StatusDone
+}
+
+// SearchStream searches every package manager concurrently, giving each one
+// at most the search timeout, and calls fn as each of them starts and
+// finishes: first with a StatusSearching event for every package manager in
+// registration order, then with one final event per package manager as it
+// responds. fn is never called concurrently, and SearchStream returns once
+// every package manager has finished.
+func (m *Manager) SearchStream(ctx context.Context, query string, fn func(SearchEvent)) {
+	var mu sync.Mutex
+	emit := func(ev SearchEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		fn(ev)
+	}
+
+	for _, pm := range m.managers {
+		emit(SearchEvent{Provider: pm.GetName(), Status: StatusSearching})
+	}
+
+	var wg sync.WaitGroup
+	for _, pm := range m.managers {
+		wg.Add(1)
+		go func(pm PackageManager) {
+			defer wg.Done()
+			pkgs, installed, available, err := m.search(ctx, pm, query)
+			switch {
+			case err != nil:
+				emit(SearchEvent{Provider: pm.GetName(), Status: StatusFailed, Err: err})
+			case !available:
+				emit(SearchEvent{Provider: pm.GetName(), Status: StatusUnavailable})
+			default:
+				emit(SearchEvent{Provider: pm.GetName(), Status: StatusDone, Packages: pkgs, Installed: installed})
+			}
+		}(pm)
+	}
+	wg.Wait()
+}
+
+// SearchAcrossAll searches every available package manager concurrently,
+// giving each one at most the search timeout. Results are grouped by package
+// manager in registration order. Package managers that fail or time out are
+// reported in a *MultiError returned along with the results of the others;
+// unavailable ones are skipped unless they are RegistrySearchers.
+func (m *Manager) SearchAcrossAll(ctx context.Context, query string) ([]Package, error) {
+	events := make(map[string]SearchEvent, len(m.managers))
+	m.SearchStream(ctx, query, func(ev SearchEvent) {
+		events[ev.Provider] = ev
+	})
+
+	results := make([]Package, 0)
+	var failed []*ProviderError
+	for _, pm := range m.managers {
+		ev := events[pm.GetName()]
+		results = append(results, ev.Packages...)
+		if ev.Err != nil {
+			failed = append(failed, &ProviderError{Provider: ev.Provider, Err: ev.Err})
+		}
+	}
+
+	if len(failed) > 0 {
+		return results, &MultiError{Errors: failed}
+	}
+	return results, nil
+}
+
+// search runs one package manager's search within the search timeout and
+// reports whether the package manager is installed and whether it could be
+// searched: it is installed or searches its registry without being installed
+func (m *Manager) search(ctx context.Context, pm PackageManager, query string) (pkgs []Package, installed, available bool, err error) {
+	searchCtx := ctx
+	if m.searchTimeout > 0 {
+		var cancel context.CancelFunc
+		searchCtx, cancel = context.WithTimeout(ctx, m.searchTimeout)
+		defer cancel()
+	}
+
+	// Only blame the search timeout when the caller's context is still live
+	timedOut := func() bool {
+		return searchCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil
+	}
+
+	installed = pm.IsAvailable(searchCtx)
+	if !installed && !SearchesRegistry(pm) {
+		if timedOut() {
+			return nil, false, true, fmt.Errorf("timed out after %s", m.searchTimeout)
+		}
+		return nil, false, false, nil
+	}
+
+	pkgs, err = pm.Search(searchCtx, query)
+	if err != nil && timedOut() {
+		return nil, installed, true, fmt.Errorf("timed out after %s", m.searchTimeout)
+	}
+	return pkgs, installed, true, err
+}
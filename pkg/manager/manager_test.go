@@ -0,0 +1,268 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// stubManager is a PackageManager whose search results are fixed in advance
+type stubManager struct {
+	name      string
+	available bool
+	results   []Package
+	err       error
+	delay     time.Duration // How long Search takes
+}
+
+func (s *stubManager) Install(ctx context.Context, spec PackageSpec) error { return nil }
+func (s *stubManager) Update(ctx context.Context, pkg string) error        { return nil }
+func (s *stubManager) Remove(ctx context.Context, pkg string) error        { return nil }
+func (s *stubManager) ListInstalled(ctx context.Context) ([]Package, error) {
+	return nil, nil
+}
+func (s *stubManager) ListOutdated(ctx context.Context) ([]OutdatedPackage, error) {
+	return nil, nil
+}
+func (s *stubManager) IsInstalled(ctx context.Context, pkg string) bool { return false }
+func (s *stubManager) IsAvailable(ctx context.Context) bool             { return s.available }
+func (s *stubManager) GetName() string                                  { return s.name }
+func (s *stubManager) Version(ctx context.Context) (string, error)      { return "1.0.0", nil }
+
+func (s *stubManager) Search(ctx context.Context, query string) ([]Package, error) {
+	select {
+	case <-time.After(s.delay):
+		return s.results, s.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func TestSameName(t *testing.T) {
+	tests := []struct {
+		provider, a, b string
+		want           bool
+	}{
+		{"pip", "requests", "requests", true},
+		{"pip", "Flask", "flask", true},
+		{"pip", "typing_extensions", "typing-extensions", true},
+		{"pip", "zope.interface", "zope-interface", true},
+		{"pip", "Ruamel.YAML--clib", "ruamel-yaml-clib", true},
+		{"npm", "lodash", "lodash", true},
+		{"npm", "lodash.get", "lodash-get", false},
+		{"npm", "JSONStream", "jsonstream", false},
+		{"npm", "lodash", "lodash-es", false},
+		{"scoop", "7zip", "7Zip", true},
+		{"scoop", "vcredist2022", "vcredist-2022", false},
+	}
+
+	for _, tt := range tests {
+		if got := SameName(tt.provider, tt.a, tt.b); got != tt.want {
+			t.Errorf("SameName(%s, %q, %q) = %v, want %v", tt.provider, tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestMatchScore(t *testing.T) {
+	for _, tt := range []struct{ query, name string }{{"black", "white"}, {"", "black"}, {"black", ""}} {
+		if got := MatchScore(tt.query, tt.name); got != 0 {
+			t.Errorf("MatchScore(%q, %q) = %v, want 0", tt.query, tt.name, got)
+		}
+	}
+
+	// The same name beats prefixes, which beat substrings; shorter names win
+	// within each
+	order := []string{"black", "black-box", "blacken-docs", "pytest-black", "flake8-black-plugin"}
+	if got := MatchScore("black", order[0]); got != 1 {
+		t.Errorf("MatchScore(black, black) = %v, want 1", got)
+	}
+	for i := 1; i < len(order); i++ {
+		if a, b := MatchScore("black", order[i-1]), MatchScore("black", order[i]); a <= b || b <= 0 {
+			t.Errorf("MatchScore(%s) = %v, MatchScore(%s) = %v, want descending above 0", order[i-1], a, order[i], b)
+		}
+	}
+}
+
+func TestFindExact(t *testing.T) {
+	m := New()
+	m.RegisterManager(&stubManager{name: "npm", available: true, results: []Package{
+		{Name: "black-box", Provider: "npm"},
+		{Name: "black", Provider: "npm"},
+	}})
+	m.RegisterManager(&stubManager{name: "pip", available: true, results: []Package{{Name: "Black", Provider: "pip"}}})
+	m.RegisterManager(&stubManager{name: "scoop", available: false, results: []Package{{Name: "black", Provider: "scoop"}}})
+
+	found, err := m.FindExact(context.Background(), "black")
+	if err != nil {
+		t.Fatalf("FindExact() error = %v", err)
+	}
+	if len(found) != 2 || found[0].Provider != "npm" || found[1].Provider != "pip" {
+		t.Errorf("FindExact() = %+v, want npm and pip matches in order", found)
+	}
+}
+
+// finderManager is a stubManager that looks exact names up in its results
+// and fails searches
+type finderManager struct {
+	stubManager
+}
+
+func (f *finderManager) Search(ctx context.Context, query string) ([]Package, error) {
+	return nil, errors.New("searched instead of looking the name up")
+}
+
+func (f *finderManager) FindPackage(ctx context.Context, name string) (Package, error) {
+	for _, pkg := range f.results {
+		if pkg.Name == name {
+			return pkg, nil
+		}
+	}
+	return Package{}, &NotFoundError{Provider: f.name, Name: name}
+}
+
+func TestFindExactWithFinder(t *testing.T) {
+	m := New()
+	m.RegisterManager(&finderManager{stubManager{name: "pip", available: true, results: []Package{{Name: "black", Provider: "pip"}}}})
+
+	found, err := m.FindExact(context.Background(), "black")
+	if err != nil || len(found) != 1 || found[0].Provider != "pip" {
+		t.Errorf("FindExact() = %+v, %v, want the pip package", found, err)
+	}
+	if found, err := m.FindExact(context.Background(), "white"); err != nil || len(found) != 0 {
+		t.Errorf("FindExact(unpublished) = %+v, %v, want nothing", found, err)
+	}
+}
+
+func TestFindExactErrors(t *testing.T) {
+	failing := &stubManager{name: "pip", available: true, err: errors.New("index unreachable")}
+
+	m := New()
+	m.RegisterManager(failing)
+	if _, err := m.FindExact(context.Background(), "black"); err == nil {
+		t.Error("FindExact() error = nil, want error when nothing was found")
+	}
+
+	m.RegisterManager(&stubManager{name: "npm", available: true, results: []Package{{Name: "black", Provider: "npm"}}})
+	found, err := m.FindExact(context.Background(), "black")
+	if err != nil {
+		t.Fatalf("FindExact() error = %v, want partial results", err)
+	}
+	if len(found) != 1 {
+		t.Errorf("FindExact() = %+v, want the npm match", found)
+	}
+}
+
+func TestSearchAcrossAll(t *testing.T) {
+	m := New(WithSearchTimeout(50 * time.Millisecond))
+	m.RegisterManager(&stubManager{name: "npm", available: true, results: []Package{{Name: "lodash", Provider: "npm"}}})
+	m.RegisterManager(&stubManager{name: "pip", available: true, err: errors.New("index unreachable")})
+	m.RegisterManager(&stubManager{name: "scoop", available: false, results: []Package{{Name: "ignored"}}})
+	m.RegisterManager(&stubManager{name: "slow", available: true, delay: time.Minute})
+	m.RegisterManager(&stubManager{name: "brew", available: true, results: []Package{{Name: "lodash-cli", Provider: "brew"}}})
+
+	start := time.Now()
+	pkgs, err := m.SearchAcrossAll(context.Background(), "lodash")
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("SearchAcrossAll() took %s, want it bounded by the search timeout", elapsed)
+	}
+
+	if len(pkgs) != 2 || pkgs[0].Provider != "npm" || pkgs[1].Provider != "brew" {
+		t.Errorf("SearchAcrossAll() = %+v, want the npm and brew results", pkgs)
+	}
+
+	var multi *MultiError
+	if !errors.As(err, &multi) {
+		t.Fatalf("SearchAcrossAll() error = %v, want *MultiError", err)
+	}
+	if len(multi.Errors) != 2 || multi.Errors[0].Provider != "pip" || multi.Errors[1].Provider != "slow" {
+		t.Fatalf("MultiError.Errors = %v", multi.Errors)
+	}
+	if got := multi.Errors[1].Error(); got != "slow: timed out after 50ms" {
+		t.Errorf("timeout error = %q", got)
+	}
+	if byProvider := multi.ByProvider(); byProvider["pip"] == nil || len(byProvider) != 2 {
+		t.Errorf("ByProvider() = %v", byProvider)
+	}
+}
+
+// registryManager is a stubManager that searches without being installed
+type registryManager struct {
+	stubManager
+}
+
+func (r *registryManager) SearchesRegistry() bool { return true }
+
+func TestSearchAcrossAllRegistrySearchers(t *testing.T) {
+	m := New()
+	m.RegisterManager(&registryManager{stubManager{name: "pip", results: []Package{{Name: "black", Provider: "pip"}}}})
+	m.RegisterManager(&stubManager{name: "scoop", results: []Package{{Name: "black", Provider: "scoop"}}})
+
+	pkgs, err := m.SearchAcrossAll(context.Background(), "black")
+	if err != nil || len(pkgs) != 1 || pkgs[0].Provider != "pip" {
+		t.Errorf("SearchAcrossAll() = %+v, %v, want the pip results only", pkgs, err)
+	}
+}
+
+func TestSearchStreamInstalled(t *testing.T) {
+	m := New()
+	m.RegisterManager(&registryManager{stubManager{name: "pip", results: []Package{{Name: "black", Provider: "pip"}}}})
+	m.RegisterManager(&stubManager{name: "npm", available: true})
+
+	installed := make(map[string]bool)
+	m.SearchStream(context.Background(), "black", func(ev SearchEvent) {
+		if ev.Status == StatusDone {
+			installed[ev.Provider] = ev.Installed
+		}
+	})
+
+	if want := map[string]bool{"pip": false, "npm": true}; len(installed) != 2 || installed["pip"] != want["pip"] || installed["npm"] != want["npm"] {
+		t.Errorf("installed = %v, want %v", installed, want)
+	}
+}
+
+func TestSearchAcrossAllSucceeds(t *testing.T) {
+	m := New()
+	m.RegisterManager(&stubManager{name: "npm", available: true, results: []Package{{Name: "lodash", Provider: "npm"}}})
+
+	pkgs, err := m.SearchAcrossAll(context.Background(), "lodash")
+	if err != nil || len(pkgs) != 1 {
+		t.Errorf("SearchAcrossAll() = %+v, %v", pkgs, err)
+	}
+}
+
+func TestSearchStream(t *testing.T) {
+	m := New()
+	m.RegisterManager(&stubManager{name: "npm", available: true, delay: 20 * time.Millisecond, results: []Package{{Name: "lodash", Provider: "npm"}}})
+	m.RegisterManager(&stubManager{name: "pip", available: true, err: errors.New("index unreachable")})
+	m.RegisterManager(&stubManager{name: "scoop", available: false})
+
+	var events []SearchEvent
+	m.SearchStream(context.Background(), "lodash", func(ev SearchEvent) {
+		events = append(events, ev)
+	})
+
+	if len(events) != 6 {
+		t.Fatalf("SearchStream() sent %d events, want 6: %+v", len(events), events)
+	}
+	for i, provider := range []string{"npm", "pip", "scoop"} {
+		if events[i].Provider != provider || events[i].Status != StatusSearching {
+			t.Errorf("event %d = %+v, want %s searching", i, events[i], provider)
+		}
+	}
+
+	// The slow npm search finishes last
+	final := make(map[string]SearchEvent)
+	for _, ev := range events[3:] {
+		final[ev.Provider] = ev
+	}
+	if events[5].Provider != "npm" || events[5].Status != StatusDone || len(events[5].Packages) != 1 {
+		t.Errorf("last event = %+v, want npm done with 1 package", events[5])
+	}
+	if ev := final["pip"]; ev.Status != StatusFailed || ev.Err == nil {
+		t.Errorf("pip event = %+v", ev)
+	}
+	if ev := final["scoop"]; ev.Status != StatusUnavailable {
+		t.Errorf("scoop event = %+v", ev)
+	}
+}
@@ -1,109 +1,357 @@
 package npm
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"os/exec"
+	"net/http"
+	"strings"
 
+	"github.com/RichestHumanAlive/ppm_cli/pkg/executor"
 	"github.com/RichestHumanAlive/ppm_cli/pkg/manager"
 )
 
-type NPMManager struct{}
+type NPMManager struct {
+	exec     executor.Executor
+	registry *registryClient
+}
+
+// Option configures a NPMManager
+type Option func(*NPMManager)
+
+// WithExecutor makes the manager run npm through e instead of the system executor
+func WithExecutor(e executor.Executor) Option {
+	return func(m *NPMManager) {
+		m.exec = e
+	}
+}
+
+// WithRegistry makes the manager query the registry at url instead of the one
+// configured in .npmrc. Scoped registries configured in .npmrc still apply
+func WithRegistry(url string) Option {
+	return func(m *NPMManager) {
+		m.registry.baseURL = url
+	}
+}
+
+// WithNpmrc makes the manager read registry and auth settings from the given
+// .npmrc files instead of the user and project ones
+func WithNpmrc(paths ...string) Option {
+	return func(m *NPMManager) {
+		m.registry.npmrc = LoadNpmrc(paths...)
+	}
+}
+
+// WithHTTPClient makes the manager use c for registry requests
+func WithHTTPClient(c *http.Client) Option {
+	return func(m *NPMManager) {
+		m.registry.http = c
+	}
+}
 
-func New() *NPMManager {
-	return &NPMManager{}
+func New(opts ...Option) *NPMManager {
+	m := &NPMManager{
+		exec: executor.System{},
+		registry: &registryClient{
+			npmrc: LoadNpmrc(defaultNpmrcPaths()...),
+			http:  http.DefaultClient,
+		},
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
 }
 
 func (n *NPMManager) GetName() string {
 	return "npm"
 }
 
-func (n *NPMManager) Install(pkg string) error {
-	cmd := exec.Command("npm", "install", "-g", pkg)
-	output, err := cmd.CombinedOutput()
+func (n *NPMManager) Install(ctx context.Context, spec manager.PackageSpec) error {
+	pkg, err := FormatSpec(spec)
+	if err != nil {
+		return err
+	}
+
+	output, err := n.exec.CombinedOutput(ctx, "npm", "install", "-g", pkg)
 	if err != nil {
 		return fmt.Errorf("npm install failed: %v\n%s", err, string(output))
 	}
 	return nil
 }
 
-type NPMSearchResult struct {
-	Objects []struct {
-		Package struct {
-			Name        string  `json:"name"`
-			Version     string  `json:"version"`
-			Description string  `json:"description"`
-			Author      struct {
-				Name string `json:"name"`
-			} `json:"author"`
-			Links struct {
-				Homepage   string `json:"homepage"`
-				Repository string `json:"repository"`
-			} `json:"links"`
-			Score struct {
-				Final float64 `json:"final"`
-			} `json:"score"`
-		} `json:"package"`
-	} `json:"objects"`
-}
-
-func (n *NPMManager) Search(query string) ([]manager.Package, error) {
-	cmd := exec.Command("npm", "search", "--json", query)
-	output, err := cmd.Output()
-	if err != nil {
+// FormatSpec translates a package spec into npm's "name@range" syntax. pip
+// style "==" pins and comma separated comparisons are converted; operators
+// without a semver equivalent are rejected.
+func FormatSpec(spec manager.PackageSpec) (string, error) {
+	if len(spec.Extras) > 0 {
+		return "", fmt.Errorf("npm does not support extras (%s)", spec)
+	}
+	if spec.Constraint == "" {
+		return spec.Name, nil
+	}
+
+	constraint := spec.Constraint
+	switch spec.ConstraintOperator() {
+	case "~=", "!=", "===":
+		return "", fmt.Errorf("npm cannot express the version constraint %q", spec.Constraint)
+	case "==":
+		constraint = strings.TrimPrefix(constraint, "==")
+	}
+	constraint = strings.Join(strings.FieldsFunc(constraint, func(r rune) bool {
+		return r == ',' || r == ' '
+	}), " ")
+
+	return spec.Name + "@" + constraint, nil
+}
+
+// Search queries the registry search API, falling back to "npm search" only
+// when the registry cannot be reached, e.g. behind a proxy only npm knows about
+func (n *NPMManager) Search(ctx context.Context, query string) ([]manager.Package, error) {
+	packages, err := n.registry.search(ctx, query)
+	if err == nil {
+		return packages, nil
+	}
+	if _, unreachable := err.(*errUnreachable); !unreachable {
 		return nil, fmt.Errorf("npm search failed: %v", err)
 	}
 
-	var result NPMSearchResult
-	if err := json.Unmarshal(output, &result); err != nil {
+	output, cliErr := n.exec.Output(ctx, "npm", "search", "--json", query)
+	if cliErr != nil {
+		return nil, fmt.Errorf("npm search failed: %v (npm CLI: %v)", err, cliErr)
+	}
+
+	return parseCLISearch(output)
+}
+
+// SearchesRegistry reports that Search works without npm installed: the CLI
+// is only a fallback for registries PPM cannot reach itself
+func (n *NPMManager) SearchesRegistry() bool {
+	return true
+}
+
+// parseCLISearch parses "npm search --json" output, which lists the search
+// result packages without the surrounding objects
+func parseCLISearch(output []byte) ([]manager.Package, error) {
+	var entries []struct {
+		Name        string   `json:"name"`
+		Version     string   `json:"version"`
+		Date        string   `json:"date"`
+		Description string   `json:"description"`
+		Keywords    []string `json:"keywords"`
+		Author      person   `json:"author"`
+		Publisher   person   `json:"publisher"`
+		Maintainers []person `json:"maintainers"`
+		Links       struct {
+			Homepage   string `json:"homepage"`
+			Repository string `json:"repository"`
+		} `json:"links"`
+	}
+	if err := json.Unmarshal(output, &entries); err != nil {
 		return nil, fmt.Errorf("failed to parse npm search results: %v", err)
 	}
 
-	packages := make([]manager.Package, 0, len(result.Objects))
-	for _, obj := range result.Objects {
-		pkg := manager.Package{
-			Name:        obj.Package.Name,
-			Version:     obj.Package.Version,
-			Description: obj.Package.Description,
-			Author:      obj.Package.Author.Name,
-			Provider:    "npm",
-			Score:      obj.Package.Score.Final,
-			Homepage:   obj.Package.Links.Homepage,
-			Repository: obj.Package.Links.Repository,
+	packages := make([]manager.Package, 0, len(entries))
+	for _, e := range entries {
+		author := e.Author.display()
+		if author == "" {
+			author = e.Publisher.display()
 		}
-		packages = append(packages, pkg)
+		packages = append(packages, manager.Package{
+			Name:        e.Name,
+			Version:     e.Version,
+			Description: e.Description,
+			Author:      author,
+			Provider:    "npm",
+			Homepage:    e.Links.Homepage,
+			Repository:  e.Links.Repository,
+			Keywords:    e.Keywords,
+			Maintainers: maintainerNames(e.Maintainers),
+			Updated:     releaseDate(e.Date),
+		})
 	}
 
 	return packages, nil
 }
 
-func (n *NPMManager) Update(pkg string) error {
+// GetInfo returns the registry metadata of a package with every published
+// version and the dependencies of the latest one
+func (n *NPMManager) GetInfo(ctx context.Context, name string) (manager.PackageInfo, error) {
+	doc, err := n.registry.fetchPackument(ctx, name)
+	if err == errNotFound {
+		return manager.PackageInfo{}, &manager.NotFoundError{Provider: "npm", Name: name}
+	}
+	if err != nil {
+		return manager.PackageInfo{}, fmt.Errorf("npm info lookup failed: %v", err)
+	}
+	return doc.info(), nil
+}
+
+func (n *NPMManager) Update(ctx context.Context, pkg string) error {
 	args := []string{"update", "-g"}
 	if pkg != "" {
 		args = append(args, pkg)
 	}
-	
-	cmd := exec.Command("npm", args...)
-	output, err := cmd.CombinedOutput()
+
+	output, err := n.exec.CombinedOutput(ctx, "npm", args...)
 	if err != nil {
 		return fmt.Errorf("npm update failed: %v\n%s", err, string(output))
 	}
 	return nil
 }
 
-func (n *NPMManager) Remove(pkg string) error {
-	cmd := exec.Command("npm", "uninstall", "-g", pkg)
-	output, err := cmd.CombinedOutput()
+func (n *NPMManager) Remove(ctx context.Context, pkg string) error {
+	output, err := n.exec.CombinedOutput(ctx, "npm", "uninstall", "-g", pkg)
 	if err != nil {
 		return fmt.Errorf("npm uninstall failed: %v\n%s", err, string(output))
 	}
 	return nil
 }
 
-func (n *NPMManager) IsAvailable() bool {
-	cmd := exec.Command("npm", "--version")
-	if err := cmd.Run(); err != nil {
+type NPMListResult struct {
+	Dependencies map[string]struct {
+		Version  string `json:"version"`
+		Resolved string `json:"resolved"`
+	} `json:"dependencies"`
+}
+
+func (n *NPMManager) ListInstalled(ctx context.Context) ([]manager.Package, error) {
+	// npm ls exits non-zero on problems such as missing peer dependencies
+	// while still printing the tree, so only fail when nothing was printed
+	output, err := n.exec.Output(ctx, "npm", "ls", "-g", "--depth=0", "--json")
+	if err != nil && len(output) == 0 {
+		return nil, fmt.Errorf("npm ls failed: %v", err)
+	}
+
+	var result NPMListResult
+	if err := json.Unmarshal(output, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse npm ls output: %v", err)
+	}
+
+	packages := make([]manager.Package, 0, len(result.Dependencies))
+	for name, dep := range result.Dependencies {
+		source := "registry"
+		if strings.HasPrefix(dep.Resolved, "file:") {
+			source = "link"
+		}
+		packages = append(packages, manager.Package{
+			Name:     name,
+			Version:  dep.Version,
+			Provider: "npm",
+			Source:   source,
+			Scope:    "global",
+		})
+	}
+
+	return packages, nil
+}
+
+// ListTopLevel lists the globally installed packages, which npm keeps apart
+// from their dependencies
+func (n *NPMManager) ListTopLevel(ctx context.Context) ([]manager.Package, error) {
+	return n.ListInstalled(ctx)
+}
+
+func (n *NPMManager) ListOutdated(ctx context.Context) ([]manager.OutdatedPackage, error) {
+	// npm outdated exits with status 1 whenever something is outdated
+	output, err := n.exec.Output(ctx, "npm", "outdated", "-g", "--json")
+	if err != nil && len(output) == 0 {
+		return nil, fmt.Errorf("npm outdated failed: %v", err)
+	}
+	if len(strings.TrimSpace(string(output))) == 0 {
+		return nil, nil
+	}
+
+	var result map[string]struct {
+		Current string `json:"current"`
+		Wanted  string `json:"wanted"`
+		Latest  string `json:"latest"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse npm outdated output: %v", err)
+	}
+
+	packages := make([]manager.OutdatedPackage, 0, len(result))
+	for name, dep := range result {
+		packages = append(packages, manager.OutdatedPackage{
+			Name:     name,
+			Provider: "npm",
+			Current:  dep.Current,
+			Wanted:   dep.Wanted,
+			Latest:   dep.Latest,
+		})
+	}
+
+	return packages, nil
+}
+
+func (n *NPMManager) IsInstalled(ctx context.Context, pkg string) bool {
+	// npm ls exits non-zero when the package is missing from the global tree
+	if err := n.exec.Run(ctx, "npm", "ls", "-g", "--depth=0", pkg); err != nil {
 		return false
 	}
 	return true
 }
+
+func (n *NPMManager) IsAvailable(ctx context.Context) bool {
+	if err := n.exec.Run(ctx, "npm", "--version"); err != nil {
+		return false
+	}
+	return true
+}
+
+func (n *NPMManager) Version(ctx context.Context) (string, error) {
+	output, err := n.exec.Output(ctx, "npm", "--version")
+	if err != nil {
+		return "", fmt.Errorf("npm --version failed: %v", err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// Resolve pins a spec to a published version using the registry packument.
+// The constraint may be empty (latest), an exact version, a dist-tag such as
+// "next" or a range, which resolves to the highest version it allows.
+func (n *NPMManager) Resolve(ctx context.Context, spec manager.PackageSpec) (manager.ResolvedPackage, error) {
+	doc, err := n.registry.fetchPackument(ctx, spec.Name)
+	if err != nil {
+		return manager.ResolvedPackage{}, fmt.Errorf("npm resolve failed: %v", err)
+	}
+
+	version, ok := spec.ExactVersion()
+	switch {
+	case spec.Constraint == "":
+		version = doc.DistTags["latest"]
+	case doc.DistTags[spec.Constraint] != "":
+		version = doc.DistTags[spec.Constraint]
+	case !ok:
+		constraint := spec.Constraint
+		if spec.ConstraintOperator() == "==" {
+			constraint = strings.TrimPrefix(constraint, "==")
+		}
+		published := make([]string, 0, len(doc.Versions))
+		for v := range doc.Versions {
+			published = append(published, v)
+		}
+		version, ok, err = manager.MaxSatisfying(published, constraint)
+		if err != nil {
+			return manager.ResolvedPackage{}, fmt.Errorf("cannot resolve %s: %v", spec, err)
+		}
+		if !ok {
+			return manager.ResolvedPackage{}, fmt.Errorf("%s has no published version matching %s", spec.Name, spec.Constraint)
+		}
+	}
+
+	release, ok := doc.Versions[version]
+	if !ok {
+		return manager.ResolvedPackage{}, fmt.Errorf("%s has no published version %s", spec.Name, version)
+	}
+
+	resolved := manager.ResolvedPackage{Name: spec.Name, Version: version}
+	switch {
+	case release.Dist.Integrity != "":
+		resolved.Hashes = []string{release.Dist.Integrity}
+	case release.Dist.Shasum != "":
+		resolved.Hashes = []string{"sha1:" + release.Dist.Shasum}
+	}
+	return resolved, nil
+}
@@ -0,0 +1,251 @@
+package npm
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/RichestHumanAlive/ppm_cli/pkg/executor/executortest"
+	"github.com/RichestHumanAlive/ppm_cli/pkg/manager"
+)
+
+func TestInstall(t *testing.T) {
+	fake := executortest.New().
+		On("npm install -g lodash", executortest.Response{Stdout: "added 1 package"})
+
+	if err := New(WithExecutor(fake)).Install(context.Background(), manager.PackageSpec{Name: "lodash"}); err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+	if !fake.Called("npm install -g lodash") {
+		t.Errorf("calls = %v, want npm install -g lodash", fake.Calls())
+	}
+}
+
+func TestInstallFailure(t *testing.T) {
+	fake := executortest.New().
+		On("npm install -g nope", executortest.Response{Stderr: "npm ERR! 404 Not Found", ExitCode: 1})
+
+	err := New(WithExecutor(fake)).Install(context.Background(), manager.PackageSpec{Name: "nope"})
+	if err == nil {
+		t.Fatal("Install() error = nil, want error")
+	}
+	if !strings.Contains(err.Error(), "404 Not Found") {
+		t.Errorf("Install() error = %q, want npm output included", err)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	tests := []struct {
+		pkg     string
+		cmdline string
+	}{
+		{"lodash", "npm update -g lodash"},
+		{"", "npm update -g"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cmdline, func(t *testing.T) {
+			fake := executortest.New().On(tt.cmdline, executortest.Response{})
+			if err := New(WithExecutor(fake)).Update(context.Background(), tt.pkg); err != nil {
+				t.Fatalf("Update(%q) error = %v", tt.pkg, err)
+			}
+		})
+	}
+}
+
+func TestUpdateFailure(t *testing.T) {
+	fake := executortest.New().On("npm update -g lodash", executortest.Response{ExitCode: 1})
+	if err := New(WithExecutor(fake)).Update(context.Background(), "lodash"); err == nil {
+		t.Error("Update() error = nil, want error")
+	}
+}
+
+func TestRemove(t *testing.T) {
+	fake := executortest.New().On("npm uninstall -g lodash", executortest.Response{})
+	if err := New(WithExecutor(fake)).Remove(context.Background(), "lodash"); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+
+	fake = executortest.New().On("npm uninstall -g lodash", executortest.Response{ExitCode: 1})
+	if err := New(WithExecutor(fake)).Remove(context.Background(), "lodash"); err == nil {
+		t.Error("Remove() error = nil, want error")
+	}
+}
+
+func TestListInstalled(t *testing.T) {
+	fake := executortest.New().
+		On("npm ls -g --depth=0 --json", executortest.Response{Stdout: `{"dependencies":{
+			"typescript":{"version":"5.4.5"},
+			"mytool":{"version":"1.0.0","resolved":"file:../mytool"}}}`})
+
+	pkgs, err := New(WithExecutor(fake)).ListInstalled(context.Background())
+	if err != nil {
+		t.Fatalf("ListInstalled() error = %v", err)
+	}
+
+	got := make(map[string]string)
+	for _, pkg := range pkgs {
+		got[pkg.Name] = pkg.Version + " " + pkg.Source
+	}
+	want := map[string]string{
+		"typescript": "5.4.5 registry",
+		"mytool":     "1.0.0 link",
+	}
+	for name, w := range want {
+		if got[name] != w {
+			t.Errorf("ListInstalled()[%s] = %q, want %q", name, got[name], w)
+		}
+	}
+}
+
+func TestListInstalledToleratesTreeProblems(t *testing.T) {
+	fake := executortest.New().
+		On("npm ls -g --depth=0 --json", executortest.Response{
+			Stdout:   `{"dependencies":{"typescript":{"version":"5.4.5"}}}`,
+			ExitCode: 1,
+		})
+
+	pkgs, err := New(WithExecutor(fake)).ListInstalled(context.Background())
+	if err != nil {
+		t.Fatalf("ListInstalled() error = %v", err)
+	}
+	if len(pkgs) != 1 {
+		t.Errorf("ListInstalled() returned %d packages, want 1", len(pkgs))
+	}
+}
+
+func TestListInstalledFailure(t *testing.T) {
+	fake := executortest.New().On("npm ls -g --depth=0 --json", executortest.Response{ExitCode: 1})
+	if _, err := New(WithExecutor(fake)).ListInstalled(context.Background()); err == nil {
+		t.Error("ListInstalled() error = nil, want error")
+	}
+}
+
+func TestListOutdated(t *testing.T) {
+	fake := executortest.New().
+		On("npm outdated -g --json", executortest.Response{
+			Stdout:   `{"typescript":{"current":"5.0.0","wanted":"5.4.5","latest":"5.4.5"}}`,
+			ExitCode: 1,
+		})
+
+	pkgs, err := New(WithExecutor(fake)).ListOutdated(context.Background())
+	if err != nil {
+		t.Fatalf("ListOutdated() error = %v", err)
+	}
+	if len(pkgs) != 1 {
+		t.Fatalf("ListOutdated() returned %d packages, want 1", len(pkgs))
+	}
+	if pkg := pkgs[0]; pkg.Name != "typescript" || pkg.Current != "5.0.0" || pkg.Latest != "5.4.5" || pkg.Provider != "npm" {
+		t.Errorf("ListOutdated() = %+v", pkg)
+	}
+}
+
+func TestListOutdatedNothingOutdated(t *testing.T) {
+	fake := executortest.New().On("npm outdated -g --json", executortest.Response{})
+
+	pkgs, err := New(WithExecutor(fake)).ListOutdated(context.Background())
+	if err != nil {
+		t.Fatalf("ListOutdated() error = %v", err)
+	}
+	if len(pkgs) != 0 {
+		t.Errorf("ListOutdated() returned %d packages, want 0", len(pkgs))
+	}
+}
+
+func TestListOutdatedFailure(t *testing.T) {
+	fake := executortest.New().On("npm outdated -g --json", executortest.Response{Stdout: "{broken", ExitCode: 1})
+	if _, err := New(WithExecutor(fake)).ListOutdated(context.Background()); err == nil {
+		t.Error("ListOutdated() error = nil, want error")
+	}
+}
+
+func TestIsInstalled(t *testing.T) {
+	fake := executortest.New().
+		On("npm ls -g --depth=0 typescript", executortest.Response{}).
+		On("npm ls -g --depth=0 missing", executortest.Response{ExitCode: 1})
+
+	n := New(WithExecutor(fake))
+	if !n.IsInstalled(context.Background(), "typescript") {
+		t.Error("IsInstalled(typescript) = false, want true")
+	}
+	if n.IsInstalled(context.Background(), "missing") {
+		t.Error("IsInstalled(missing) = true, want false")
+	}
+}
+
+func TestIsAvailable(t *testing.T) {
+	fake := executortest.New().On("npm --version", executortest.Response{Stdout: "10.8.2"})
+	if !New(WithExecutor(fake)).IsAvailable(context.Background()) {
+		t.Error("IsAvailable() = false, want true")
+	}
+
+	// An unscripted command behaves like a missing binary
+	if New(WithExecutor(executortest.New())).IsAvailable(context.Background()) {
+		t.Error("IsAvailable() = true, want false")
+	}
+}
+
+func TestInstallCancelled(t *testing.T) {
+	fake := executortest.New().On("npm install -g lodash", executortest.Response{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := New(WithExecutor(fake)).Install(ctx, manager.PackageSpec{Name: "lodash"})
+	if err == nil || !strings.Contains(err.Error(), context.Canceled.Error()) {
+		t.Errorf("Install() error = %v, want context canceled", err)
+	}
+	if fake.Called("npm install -g lodash") {
+		t.Error("npm install ran with a cancelled context")
+	}
+}
+
+func TestInstallWithConstraint(t *testing.T) {
+	fake := executortest.New().On("npm install -g typescript@^5.4", executortest.Response{})
+
+	spec := manager.PackageSpec{Name: "typescript", Constraint: "^5.4"}
+	if err := New(WithExecutor(fake)).Install(context.Background(), spec); err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+}
+
+func TestFormatSpec(t *testing.T) {
+	tests := []struct {
+		spec    manager.PackageSpec
+		want    string
+		wantErr bool
+	}{
+		{spec: manager.PackageSpec{Name: "lodash"}, want: "lodash"},
+		{spec: manager.PackageSpec{Name: "lodash", Constraint: "4.17.21"}, want: "lodash@4.17.21"},
+		{spec: manager.PackageSpec{Name: "@types/node", Constraint: "^20"}, want: "@types/node@^20"},
+		{spec: manager.PackageSpec{Name: "lodash", Constraint: "==4.17.21"}, want: "lodash@4.17.21"},
+		{spec: manager.PackageSpec{Name: "lodash", Constraint: ">=4,<5"}, want: "lodash@>=4 <5"},
+		{spec: manager.PackageSpec{Name: "lodash", Constraint: "~=4.17"}, wantErr: true},
+		{spec: manager.PackageSpec{Name: "lodash", Constraint: "!=4.17.20"}, wantErr: true},
+		{spec: manager.PackageSpec{Name: "lodash", Extras: []string{"fp"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec.String(), func(t *testing.T) {
+			got, err := FormatSpec(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FormatSpec() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("FormatSpec() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVersion(t *testing.T) {
+	fake := executortest.New().On("npm --version", executortest.Response{Stdout: "10.5.2\n"})
+	version, err := New(WithExecutor(fake)).Version(context.Background())
+	if err != nil || version != "10.5.2" {
+		t.Errorf("Version() = %q, %v, want 10.5.2", version, err)
+	}
+
+	if _, err := New(WithExecutor(executortest.New())).Version(context.Background()); err == nil {
+		t.Error("Version() error = nil, want error")
+	}
+}
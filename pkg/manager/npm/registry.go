@@ -0,0 +1,442 @@
+package npm
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/RichestHumanAlive/ppm_cli/pkg/manager"
+)
+
+// DefaultRegistry is the registry used when neither .npmrc nor WithRegistry name one
+const DefaultRegistry = "https://registry.npmjs.org/"
+
+// searchSize is how many results a registry search asks for
+const searchSize = 20
+
+// Npmrc holds the .npmrc settings PPM needs to talk to the registry
+type Npmrc struct {
+	Registry string            // Default registry URL
+	Scopes   map[string]string // Registry URLs of scoped packages keyed by scope, e.g. "@myorg"
+	Auth     map[string]string // Authorization header values keyed by "//host/path/" prefix
+}
+
+// LoadNpmrc reads the given .npmrc files, later files overriding earlier ones.
+// Missing files are skipped. "${VAR}" references are expanded from the
+// environment like npm does, and npm_config_registry overrides the registry.
+func LoadNpmrc(paths ...string) Npmrc {
+	cfg := Npmrc{Scopes: make(map[string]string), Auth: make(map[string]string)}
+
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || line[0] == '#' || line[0] == ';' {
+				continue
+			}
+			key, value, ok := strings.Cut(line, "=")
+			if !ok {
+				continue
+			}
+			key = strings.TrimSpace(key)
+			value = os.ExpandEnv(strings.Trim(strings.TrimSpace(value), `"'`))
+
+			switch {
+			case key == "registry":
+				cfg.Registry = value
+			case strings.HasPrefix(key, "@") && strings.HasSuffix(key, ":registry"):
+				cfg.Scopes[strings.TrimSuffix(key, ":registry")] = value
+			case strings.HasPrefix(key, "//") && strings.HasSuffix(key, ":_authToken"):
+				cfg.Auth[strings.TrimSuffix(key, ":_authToken")] = "Bearer " + value
+			case strings.HasPrefix(key, "//") && strings.HasSuffix(key, ":_auth"):
+				cfg.Auth[strings.TrimSuffix(key, ":_auth")] = "Basic " + value
+			}
+		}
+		f.Close()
+	}
+
+	for _, env := range []string{"npm_config_registry", "NPM_CONFIG_REGISTRY"} {
+		if registry := os.Getenv(env); registry != "" {
+			cfg.Registry = registry
+		}
+	}
+
+	return cfg
+}
+
+// defaultNpmrcPaths returns the user and project .npmrc files npm itself reads
+func defaultNpmrcPaths() []string {
+	paths := make([]string, 0, 2)
+	if home, err := os.UserHomeDir(); err == nil {
+		paths = append(paths, filepath.Join(home, ".npmrc"))
+	}
+	if wd, err := os.Getwd(); err == nil {
+		paths = append(paths, filepath.Join(wd, ".npmrc"))
+	}
+	return paths
+}
+
+// registryClient talks to an npm registry over HTTP
+type registryClient struct {
+	baseURL string // Overrides the .npmrc registry when set
+	npmrc   Npmrc
+	http    *http.Client
+}
+
+// registry returns the registry URL with a trailing slash
+func (c *registryClient) registry() string {
+	base := c.baseURL
+	if base == "" {
+		base = c.npmrc.Registry
+	}
+	if base == "" {
+		base = DefaultRegistry
+	}
+	return strings.TrimRight(base, "/") + "/"
+}
+
+// registryFor returns the registry URL of a package: the one configured for
+// its scope, as in "@myorg:registry=...", or the default registry
+func (c *registryClient) registryFor(name string) string {
+	if scope, _, ok := strings.Cut(name, "/"); ok && strings.HasPrefix(scope, "@") {
+		if registry := c.npmrc.Scopes[scope]; registry != "" {
+			return strings.TrimRight(registry, "/") + "/"
+		}
+	}
+	return c.registry()
+}
+
+// authorization returns the Authorization header configured for u, matching
+// the longest "//host/path/" prefix like npm does
+func (c *registryClient) authorization(u *url.URL) string {
+	target := "//" + u.Host + u.Path
+	best, header := 0, ""
+	for prefix, value := range c.npmrc.Auth {
+		if strings.HasPrefix(target, strings.TrimRight(prefix, "/")+"/") && len(prefix) > best {
+			best, header = len(prefix), value
+		}
+	}
+	return header
+}
+
+// errNotFound is returned by the registry client for unknown packages
+var errNotFound = fmt.Errorf("package not found")
+
+// errUnreachable wraps transport failures, as opposed to error responses
+type errUnreachable struct {
+	err error
+}
+
+func (e *errUnreachable) Error() string {
+	return fmt.Sprintf("registry unreachable: %v", e.err)
+}
+
+func (e *errUnreachable) Unwrap() error {
+	return e.err
+}
+
+// get fetches rawURL and decodes the JSON response into v, authorizing the
+// request with the credentials configured for its registry
+func (c *registryClient) get(ctx context.Context, rawURL string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if auth := c.authorization(req.URL); auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return &errUnreachable{err: err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return errNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", req.URL, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to parse registry response: %v", err)
+	}
+	return nil
+}
+
+// person is an npm author or maintainer, which the registry sends either as
+// an object or as a "Name <email> (url)" string
+type person struct {
+	Name     string `json:"name"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+func (p *person) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		if i := strings.IndexAny(s, "<("); i >= 0 {
+			s = s[:i]
+		}
+		p.Name = strings.TrimSpace(s)
+		return nil
+	}
+
+	type plain person
+	return json.Unmarshal(data, (*plain)(p))
+}
+
+// display returns the name to show for the person
+func (p person) display() string {
+	if p.Name != "" {
+		return p.Name
+	}
+	return p.Username
+}
+
+// NPMSearchResult is the response of the registry search endpoint
+// (/-/v1/search), which "npm search --json" also prints in a flattened form
+type NPMSearchResult struct {
+	Objects []struct {
+		Package struct {
+			Name        string   `json:"name"`
+			Version     string   `json:"version"`
+			Date        string   `json:"date"`
+			Description string   `json:"description"`
+			Keywords    []string `json:"keywords"`
+			License     string   `json:"license"`
+			Author      person   `json:"author"`
+			Publisher   person   `json:"publisher"`
+			Maintainers []person `json:"maintainers"`
+			Links       struct {
+				Homepage   string `json:"homepage"`
+				Repository string `json:"repository"`
+			} `json:"links"`
+		} `json:"package"`
+		Score struct {
+			Final float64 `json:"final"`
+		} `json:"score"`
+		Downloads struct {
+			Weekly  int64 `json:"weekly"`
+			Monthly int64 `json:"monthly"`
+		} `json:"downloads"`
+	} `json:"objects"`
+}
+
+// packages converts search results into manager packages
+func (r NPMSearchResult) packages() []manager.Package {
+	packages := make([]manager.Package, 0, len(r.Objects))
+	for _, obj := range r.Objects {
+		author := obj.Package.Author.display()
+		if author == "" {
+			author = obj.Package.Publisher.display()
+		}
+
+		packages = append(packages, manager.Package{
+			Name:        obj.Package.Name,
+			Version:     obj.Package.Version,
+			Description: obj.Package.Description,
+			Author:      author,
+			Provider:    "npm",
+			Score:       obj.Score.Final,
+			Downloads:   obj.Downloads.Monthly,
+			Homepage:    obj.Package.Links.Homepage,
+			Repository:  obj.Package.Links.Repository,
+			License:     obj.Package.License,
+			Keywords:    obj.Package.Keywords,
+			Maintainers: maintainerNames(obj.Package.Maintainers),
+			Updated:     releaseDate(obj.Package.Date),
+		})
+	}
+	return packages
+}
+
+// search queries the registry search endpoint
+func (c *registryClient) search(ctx context.Context, query string) ([]manager.Package, error) {
+	params := url.Values{}
+	params.Set("text", query)
+	params.Set("size", fmt.Sprint(searchSize))
+
+	var result NPMSearchResult
+	if err := c.get(ctx, c.registry()+"-/v1/search?"+params.Encode(), &result); err != nil {
+		return nil, err
+	}
+	return result.packages(), nil
+}
+
+// packument is the subset of a registry package document PPM reads
+type packument struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	DistTags    map[string]string `json:"dist-tags"`
+	Versions    map[string]struct {
+		Version      string            `json:"version"`
+		Description  string            `json:"description"`
+		License      license           `json:"license"`
+		Keywords     []string          `json:"keywords"`
+		Homepage     string            `json:"homepage"`
+		Author       person            `json:"author"`
+		Dependencies map[string]string `json:"dependencies"`
+		Dist         struct {
+			Integrity string `json:"integrity"`
+			Shasum    string `json:"shasum"`
+			Tarball   string `json:"tarball"`
+		} `json:"dist"`
+	} `json:"versions"`
+	Maintainers []person          `json:"maintainers"`
+	Repository  repository        `json:"repository"`
+	Time        map[string]string `json:"time"` // Publication times by version, RFC 3339
+}
+
+// license is a package license, sent either as an SPDX string or, by old
+// packages, as a {"type": ...} object
+type license string
+
+func (l *license) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*l = license(s)
+		return nil
+	}
+	var obj struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	*l = license(obj.Type)
+	return nil
+}
+
+// repository is a package repository, sent either as a URL string or as a
+// {"type": "git", "url": ...} object
+type repository string
+
+func (r *repository) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*r = repository(s)
+		return nil
+	}
+	var obj struct {
+		URL string `json:"url"`
+	}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	*r = repository(obj.URL)
+	return nil
+}
+
+// repositoryURL turns a package.json repository into a browsable URL, e.g.
+// "git+https://github.com/lodash/lodash.git" into "https://github.com/lodash/lodash"
+func repositoryURL(repo string) string {
+	repo = strings.TrimPrefix(repo, "git+")
+	repo = strings.TrimSuffix(repo, ".git")
+	if strings.HasPrefix(repo, "git://") {
+		repo = "https://" + strings.TrimPrefix(repo, "git://")
+	}
+	if strings.HasPrefix(repo, "github:") {
+		repo = "https://github.com/" + strings.TrimPrefix(repo, "github:")
+	}
+	return repo
+}
+
+// fetchPackument fetches the package document of name from its registry
+func (c *registryClient) fetchPackument(ctx context.Context, name string) (*packument, error) {
+	// Scoped names such as "@types/node" are fetched as "@types%2Fnode"
+	var doc packument
+	if err := c.get(ctx, c.registryFor(name)+url.PathEscape(name), &doc); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// latestPackage returns the metadata of the latest version in the packument
+func (p *packument) latestPackage() manager.Package {
+	pkg := manager.Package{
+		Name:        p.Name,
+		Description: p.Description,
+		Provider:    "npm",
+		Repository:  repositoryURL(string(p.Repository)),
+		Maintainers: maintainerNames(p.Maintainers),
+	}
+
+	latest, ok := p.Versions[p.DistTags["latest"]]
+	if !ok {
+		return pkg
+	}
+	pkg.Version = latest.Version
+	pkg.Author = latest.Author.display()
+	pkg.Homepage = latest.Homepage
+	pkg.License = string(latest.License)
+	pkg.Keywords = latest.Keywords
+	pkg.Updated = releaseDate(p.Time[latest.Version])
+	if latest.Description != "" {
+		pkg.Description = latest.Description
+	}
+	return pkg
+}
+
+// info returns the metadata of the latest version in the packument along with
+// every published version and the latest version's dependencies
+func (p *packument) info() manager.PackageInfo {
+	info := manager.PackageInfo{
+		Package:  p.latestPackage(),
+		Releases: make([]manager.Release, 0, len(p.Versions)),
+	}
+
+	for version := range p.Versions {
+		info.Releases = append(info.Releases, manager.Release{Version: version, Date: releaseDate(p.Time[version])})
+	}
+	manager.SortReleases(info.Releases)
+
+	if latest, ok := p.Versions[p.DistTags["latest"]]; ok {
+		for name, constraint := range latest.Dependencies {
+			info.Dependencies = append(info.Dependencies, name+"@"+constraint)
+		}
+		sort.Strings(info.Dependencies)
+	}
+	return info
+}
+
+// releaseDate converts a registry timestamp to manager.ReleaseDateFormat,
+// returning "" for missing or malformed timestamps
+func releaseDate(timestamp string) string {
+	t, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil {
+		return ""
+	}
+	return t.UTC().Format(manager.ReleaseDateFormat)
+}
+
+// maintainerNames returns the display names of maintainers
+func maintainerNames(people []person) []string {
+	if len(people) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(people))
+	for _, p := range people {
+		if name := p.display(); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
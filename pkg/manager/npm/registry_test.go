@@ -0,0 +1,327 @@
+package npm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/RichestHumanAlive/ppm_cli/pkg/executor/executortest"
+	"github.com/RichestHumanAlive/ppm_cli/pkg/manager"
+)
+
+const searchResponse = `{"objects":[{
+	"package":{"name":"lodash","version":"4.17.21","date":"2021-02-20T15:42:16.891Z","description":"Lodash modular utilities.",
+		"keywords":["modules","stdlib","util"],"license":"MIT",
+		"publisher":{"username":"jdalton"},
+		"maintainers":[{"username":"jdalton","email":"john.david.dalton@gmail.com"},{"username":"mathias"}],
+		"links":{"homepage":"https://lodash.com/","repository":"https://github.com/lodash/lodash"}},
+	"score":{"final":0.92},
+	"downloads":{"weekly":51000000,"monthly":220000000}}]}`
+
+const lodashPackument = `{"name":"lodash","description":"Lodash modular utilities.",
+	"dist-tags":{"latest":"4.17.21"},
+	"versions":{"4.17.21":{"version":"4.17.21","license":"MIT","keywords":["modules","stdlib"],
+		"homepage":"https://lodash.com/","author":"John-David Dalton <john.david.dalton@gmail.com>",
+		"dependencies":{"lodash._root":"~3.0.0"},
+		"dist":{"integrity":"sha512-v2kDEe57lecTulaDIuNTPy3Ry4gLGJ6Z1O3vE1krgXZNrsQ+LFTGHVxVjcXPs17LhbZVGedAJv8XZ1tvj5FvSg=="}},
+		"3.10.1":{"version":"3.10.1","dist":{"shasum":"5bf45e8e49ba4189e17d482789dfd15bd140b7b6"}}},
+	"maintainers":[{"name":"jdalton"}],
+	"repository":{"type":"git","url":"git+https://github.com/lodash/lodash.git"},
+	"time":{"created":"2012-04-23T16:37:11.912Z","4.17.21":"2021-02-20T15:42:16.891Z","3.10.1":"2015-08-04T06:05:06.887Z"}}`
+
+// newRegistry starts a stand-in registry that records the Authorization
+// header of the last request
+func newRegistry(t *testing.T, auth *string) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/-/v1/search", func(w http.ResponseWriter, r *http.Request) {
+		if auth != nil {
+			*auth = r.Header.Get("Authorization")
+		}
+		if r.URL.Query().Get("text") != "lodash" {
+			w.Write([]byte(`{"objects":[]}`))
+			return
+		}
+		w.Write([]byte(searchResponse))
+	})
+	mux.HandleFunc("/lodash", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(lodashPackument))
+	})
+	mux.HandleFunc("/typescript", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"name":"typescript","dist-tags":{"latest":"5.4.5","next":"5.5.0-dev.20240601"},"versions":{
+			"4.9.5":{"version":"4.9.5","dist":{"integrity":"sha512-four"}},
+			"5.0.4":{"version":"5.0.4","dist":{"integrity":"sha512-five-zero"}},
+			"5.4.5":{"version":"5.4.5","dist":{"integrity":"sha512-five-four"}},
+			"5.5.0-dev.20240601":{"version":"5.5.0-dev.20240601"}}}`))
+	})
+	mux.HandleFunc("/@types/", func(w http.ResponseWriter, r *http.Request) {
+		if auth != nil {
+			*auth = r.Header.Get("Authorization")
+		}
+		if r.URL.EscapedPath() != "/@types%2Fnode" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"name":"@types/node","dist-tags":{"latest":"20.12.12"},"versions":{"20.12.12":{"version":"20.12.12"}}}`))
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestSearch(t *testing.T) {
+	server := newRegistry(t, nil)
+	fake := executortest.New()
+
+	pkgs, err := New(WithExecutor(fake), WithRegistry(server.URL)).Search(context.Background(), "lodash")
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(pkgs) != 1 {
+		t.Fatalf("Search() returned %d packages, want 1", len(pkgs))
+	}
+
+	pkg := pkgs[0]
+	if pkg.Name != "lodash" || pkg.Version != "4.17.21" || pkg.Provider != "npm" || pkg.Score != 0.92 {
+		t.Errorf("Search() = %+v", pkg)
+	}
+	if pkg.Author != "jdalton" || pkg.Homepage != "https://lodash.com/" || pkg.Repository != "https://github.com/lodash/lodash" {
+		t.Errorf("Search() links = %+v", pkg)
+	}
+	if pkg.License != "MIT" || pkg.Downloads != 220000000 || len(pkg.Keywords) != 3 || len(pkg.Maintainers) != 2 || pkg.Updated != "2021-02-20" {
+		t.Errorf("Search() metadata = %+v", pkg)
+	}
+	if calls := fake.Calls(); len(calls) != 0 {
+		t.Errorf("calls = %v, want the npm CLI unused", calls)
+	}
+}
+
+func TestSearchFallsBackToCLI(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	fake := executortest.New().
+		On("npm search --json lodash", executortest.Response{Stdout: `[{"name":"lodash","version":"4.17.21",
+			"description":"Lodash modular utilities.","keywords":["util"],
+			"publisher":{"username":"jdalton"},"maintainers":[{"username":"jdalton"}],
+			"links":{"homepage":"https://lodash.com/"}}]`})
+
+	pkgs, err := New(WithExecutor(fake), WithRegistry(server.URL)).Search(context.Background(), "lodash")
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(pkgs) != 1 || pkgs[0].Name != "lodash" || pkgs[0].Author != "jdalton" || pkgs[0].Homepage != "https://lodash.com/" {
+		t.Errorf("Search() = %+v", pkgs)
+	}
+}
+
+func TestSearchFailure(t *testing.T) {
+	unreachable := httptest.NewServer(http.NotFoundHandler())
+	unreachable.Close()
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+
+	tests := []struct {
+		name     string
+		registry string
+		fake     *executortest.Fake
+	}{
+		{
+			name:     "registry error does not fall back",
+			registry: failing.URL,
+			fake:     executortest.New().On("npm search --json lodash", executortest.Response{Stdout: "[]"}),
+		},
+		{
+			name:     "cli fails",
+			registry: unreachable.URL,
+			fake:     executortest.New().On("npm search --json lodash", executortest.Response{Stderr: "npm ERR! network", ExitCode: 1}),
+		},
+		{
+			name:     "cli prints invalid json",
+			registry: unreachable.URL,
+			fake:     executortest.New().On("npm search --json lodash", executortest.Response{Stdout: "not json"}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(WithExecutor(tt.fake), WithRegistry(tt.registry)).Search(context.Background(), "lodash"); err == nil {
+				t.Error("Search() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestSearchUsesNpmrc(t *testing.T) {
+	var auth string
+	server := newRegistry(t, &auth)
+
+	dir := t.TempDir()
+	userrc := filepath.Join(dir, "user.npmrc")
+	projectrc := filepath.Join(dir, "project.npmrc")
+	host := server.Listener.Addr().String()
+
+	t.Setenv("NPM_TOKEN", "s3cret")
+	os.WriteFile(userrc, []byte("registry=https://registry.invalid/\n//registry.invalid/:_authToken=wrong\n"), 0o644)
+	os.WriteFile(projectrc, []byte("; project settings\nregistry = "+server.URL+"/\n//"+host+"/:_authToken=${NPM_TOKEN}\n"), 0o644)
+
+	pkgs, err := New(WithExecutor(executortest.New()), WithNpmrc(userrc, projectrc)).Search(context.Background(), "lodash")
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(pkgs) != 1 {
+		t.Errorf("Search() returned %d packages, want 1", len(pkgs))
+	}
+	if auth != "Bearer s3cret" {
+		t.Errorf("Authorization = %q, want the project token", auth)
+	}
+}
+
+func TestGetInfoUsesScopedRegistry(t *testing.T) {
+	var auth string
+	scoped := newRegistry(t, &auth)
+	public := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(public.Close)
+
+	npmrc := filepath.Join(t.TempDir(), ".npmrc")
+	host := scoped.Listener.Addr().String()
+	os.WriteFile(npmrc, []byte("registry="+public.URL+"/\n@types:registry="+scoped.URL+"/\n//"+host+"/:_authToken=scoped\n"), 0o644)
+
+	// The scope's registry wins over the default one, as it does for npm
+	for _, opts := range [][]Option{{WithNpmrc(npmrc)}, {WithNpmrc(npmrc), WithRegistry(public.URL)}} {
+		auth = ""
+		n := New(append(opts, WithExecutor(executortest.New()))...)
+		info, err := n.GetInfo(context.Background(), "@types/node")
+		if err != nil || info.Version != "20.12.12" {
+			t.Errorf("GetInfo(@types/node) = %+v, %v", info, err)
+		}
+		if auth != "Bearer scoped" {
+			t.Errorf("Authorization = %q, want the scoped registry's token", auth)
+		}
+	}
+
+	// Other packages stay on the default registry
+	n := New(WithExecutor(executortest.New()), WithNpmrc(npmrc))
+	if _, err := n.GetInfo(context.Background(), "lodash"); !isNotFound(err) {
+		t.Errorf("GetInfo(lodash) error = %v, want it looked up on the default registry", err)
+	}
+}
+
+func TestLoadNpmrc(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".npmrc")
+	os.WriteFile(path, []byte(`# comment
+registry="https://npm.example.com/"
+//npm.example.com/:_authToken=abc
+//npm.example.com/private/:_auth=dXNlcjpwYXNz
+@myorg:registry=https://npm.myorg.example.com/
+always-auth=true
+`), 0o644)
+
+	cfg := LoadNpmrc(path, filepath.Join(t.TempDir(), "missing"))
+	if cfg.Registry != "https://npm.example.com/" {
+		t.Errorf("Registry = %q", cfg.Registry)
+	}
+	if cfg.Auth["//npm.example.com/"] != "Bearer abc" || cfg.Auth["//npm.example.com/private/"] != "Basic dXNlcjpwYXNz" {
+		t.Errorf("Auth = %v", cfg.Auth)
+	}
+	if len(cfg.Scopes) != 1 || cfg.Scopes["@myorg"] != "https://npm.myorg.example.com/" {
+		t.Errorf("Scopes = %v", cfg.Scopes)
+	}
+
+	t.Setenv("npm_config_registry", "https://override.example.com/")
+	if cfg := LoadNpmrc(path); cfg.Registry != "https://override.example.com/" {
+		t.Errorf("Registry = %q, want environment override", cfg.Registry)
+	}
+}
+
+func TestGetInfo(t *testing.T) {
+	server := newRegistry(t, nil)
+	n := New(WithExecutor(executortest.New()), WithRegistry(server.URL))
+
+	info, err := n.GetInfo(context.Background(), "lodash")
+	if err != nil {
+		t.Fatalf("GetInfo() error = %v", err)
+	}
+	if info.Name != "lodash" || info.Version != "4.17.21" || info.License != "MIT" || info.Author != "John-David Dalton" {
+		t.Errorf("GetInfo() = %+v", info.Package)
+	}
+	if info.Repository != "https://github.com/lodash/lodash" || len(info.Maintainers) != 1 || len(info.Keywords) != 2 || info.Updated != "2021-02-20" {
+		t.Errorf("GetInfo() links = %+v", info.Package)
+	}
+
+	want := []manager.Release{{Version: "4.17.21", Date: "2021-02-20"}, {Version: "3.10.1", Date: "2015-08-04"}}
+	if len(info.Releases) != len(want) {
+		t.Fatalf("GetInfo() releases = %+v, want %+v", info.Releases, want)
+	}
+	for i := range want {
+		if info.Releases[i] != want[i] {
+			t.Errorf("GetInfo() releases[%d] = %+v, want %+v", i, info.Releases[i], want[i])
+		}
+	}
+	if len(info.Dependencies) != 1 || info.Dependencies[0] != "lodash._root@~3.0.0" {
+		t.Errorf("GetInfo() dependencies = %v", info.Dependencies)
+	}
+
+	info, err = n.GetInfo(context.Background(), "@types/node")
+	if err != nil || info.Version != "20.12.12" {
+		t.Errorf("GetInfo(@types/node) = %+v, %v", info.Package, err)
+	}
+
+	if _, err := n.GetInfo(context.Background(), "missing"); !isNotFound(err) {
+		t.Errorf("GetInfo(missing) error = %v, want *manager.NotFoundError", err)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	server := newRegistry(t, nil)
+	n := New(WithExecutor(executortest.New()), WithRegistry(server.URL))
+
+	for _, constraint := range []string{"", "4.17.21", "latest", "^4", ">=3 <5"} {
+		resolved, err := n.Resolve(context.Background(), manager.PackageSpec{Name: "lodash", Constraint: constraint})
+		if err != nil {
+			t.Fatalf("Resolve(%q) error = %v", constraint, err)
+		}
+		if resolved.Version != "4.17.21" || len(resolved.Hashes) != 1 || resolved.Hashes[0][:7] != "sha512-" {
+			t.Errorf("Resolve(%q) = %+v", constraint, resolved)
+		}
+	}
+
+	resolved, err := n.Resolve(context.Background(), manager.PackageSpec{Name: "lodash", Constraint: "3.x"})
+	if err != nil || resolved.Version != "3.10.1" || resolved.Hashes[0] != "sha1:5bf45e8e49ba4189e17d482789dfd15bd140b7b6" {
+		t.Errorf("Resolve(3.x) = %+v, %v", resolved, err)
+	}
+
+	for _, constraint := range []string{"4.17.20", "^5", "next"} {
+		if _, err := n.Resolve(context.Background(), manager.PackageSpec{Name: "lodash", Constraint: constraint}); err == nil {
+			t.Errorf("Resolve(%q) error = nil, want error", constraint)
+		}
+	}
+}
+
+func TestResolvePartialVersion(t *testing.T) {
+	server := newRegistry(t, nil)
+	n := New(WithExecutor(executortest.New()), WithRegistry(server.URL))
+
+	for constraint, want := range map[string]string{"5": "5.4.5", "5.0": "5.0.4", "4": "4.9.5"} {
+		resolved, err := n.Resolve(context.Background(), manager.PackageSpec{Name: "typescript", Constraint: constraint})
+		if err != nil || resolved.Version != want {
+			t.Errorf("Resolve(typescript@%s) = %+v, %v, want %s", constraint, resolved, err, want)
+		}
+	}
+	if _, err := n.Resolve(context.Background(), manager.PackageSpec{Name: "typescript", Constraint: "6"}); err == nil {
+		t.Error("Resolve(typescript@6) error = nil, want error")
+	}
+}
+
+func isNotFound(err error) bool {
+	_, ok := err.(*manager.NotFoundError)
+	return ok
+}
@@ -1,118 +1,386 @@
 package pip
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
-	"os/exec"
+	"net/http"
 	"strings"
 
+	"github.com/RichestHumanAlive/ppm_cli/pkg/executor"
 	"github.com/RichestHumanAlive/ppm_cli/pkg/manager"
 )
 
-type PIPManager struct{}
+type PIPManager struct {
+	exec  executor.Executor
+	index *pypiClient
+}
+
+// Option configures a PIPManager
+type Option func(*PIPManager)
+
+// WithExecutor makes the manager run pip through e instead of the system executor
+func WithExecutor(e executor.Executor) Option {
+	return func(m *PIPManager) {
+		m.exec = e
+	}
+}
 
-func New() *PIPManager {
-	return &PIPManager{}
+// WithIndexURL makes Search query another PyPI compatible index, e.g. a local
+// devpi server. Both the index root and its "/simple/" URL are accepted.
+func WithIndexURL(indexURL string) Option {
+	return func(m *PIPManager) {
+		m.index.baseURL = normalizeIndexURL(indexURL)
+	}
+}
+
+// WithHTTPClient makes Search use c for requests to the package index
+func WithHTTPClient(c *http.Client) Option {
+	return func(m *PIPManager) {
+		m.index.http = c
+	}
+}
+
+// WithCacheDir makes Search keep the index's project listing in dir, so that
+// it is downloaded at most once per ListingTTL rather than once per run
+func WithCacheDir(dir string) Option {
+	return func(m *PIPManager) {
+		m.index.cacheDir = dir
+	}
+}
+
+func New(opts ...Option) *PIPManager {
+	m := &PIPManager{
+		exec:  executor.System{},
+		index: &pypiClient{baseURL: DefaultIndexURL, http: http.DefaultClient},
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
 }
 
 func (p *PIPManager) GetName() string {
 	return "pip"
 }
 
-func (p *PIPManager) Install(pkg string) error {
-	cmd := exec.Command("pip", "install", pkg)
-	output, err := cmd.CombinedOutput()
+// MaxConcurrency serializes pip operations: concurrent runs race on the
+// site-packages directory they both modify
+func (p *PIPManager) MaxConcurrency() int {
+	return 1
+}
+
+func (p *PIPManager) Install(ctx context.Context, spec manager.PackageSpec) error {
+	pkg, err := FormatSpec(spec)
+	if err != nil {
+		return err
+	}
+
+	output, err := p.exec.CombinedOutput(ctx, "pip", "install", pkg)
 	if err != nil {
 		return fmt.Errorf("pip install failed: %v\n%s", err, string(output))
 	}
 	return nil
 }
 
-type PyPIResponse struct {
-	Info struct {
-		Name        string `json:"name"`
-		Version     string `json:"version"`
-		Summary     string `json:"summary"`
-		Author      string `json:"author"`
-		HomePage    string `json:"home_page"`
-		ProjectURL  string `json:"project_url"`
-		DownloadURL string `json:"download_url"`
-	} `json:"info"`
+// FormatSpec translates a package spec into a pip requirement such as
+// "requests[socks]==2.31.0". Bare versions become "==" pins, and partial ones
+// prefix matches ("black@23" is "black==23.*"); npm ranges (caret, tilde,
+// x-ranges, "||") have no pip equivalent and are rejected.
+func FormatSpec(spec manager.PackageSpec) (string, error) {
+	req := spec.Name
+	if len(spec.Extras) > 0 {
+		req += "[" + strings.Join(spec.Extras, ",") + "]"
+	}
+	if spec.Constraint == "" {
+		return req, nil
+	}
+
+	if spec.ConstraintOperator() != "" && !strings.ContainsAny(spec.Constraint, "^|") {
+		return req + spec.Constraint, nil
+	}
+	if version, ok := spec.ExactVersion(); ok {
+		return req + "==" + version, nil
+	}
+	if version, ok := spec.SingleVersion(); ok {
+		return req + "==" + version + ".*", nil
+	}
+
+	return "", fmt.Errorf("pip cannot express the version constraint %q", spec.Constraint)
 }
 
-func (p *PIPManager) Search(query string) ([]manager.Package, error) {
-	// Use pip search command (Note: pip search is deprecated, using pip index instead)
-	cmd := exec.Command("pip", "index", "versions", query)
-	output, err := cmd.CombinedOutput()
+// Search looks packages up on the package index over HTTP: the JSON API
+// answers exact names and the Simple index listing is matched fuzzily for
+// partial queries. Unlike the pip CLI this works without pip installed.
+func (p *PIPManager) Search(ctx context.Context, query string) ([]manager.Package, error) {
+	pkgs, err := p.index.search(ctx, query)
 	if err != nil {
-		// Fallback to simple package info
-		cmd = exec.Command("pip", "show", query)
-		output, err = cmd.CombinedOutput()
-		if err != nil {
-			return nil, fmt.Errorf("pip search failed: %v", err)
-		}
+		return nil, fmt.Errorf("pypi search failed: %v", err)
+	}
+	return pkgs, nil
+}
+
+// SearchesRegistry reports that Search works without pip installed
+func (p *PIPManager) SearchesRegistry() bool {
+	return true
+}
+
+// FindPackage looks a project up by name in the PyPI JSON API, without the
+// index listing Search matches partial names against
+func (p *PIPManager) FindPackage(ctx context.Context, name string) (manager.Package, error) {
+	pkg, err := p.index.project(ctx, name)
+	if err == errNotFound {
+		return manager.Package{}, &manager.NotFoundError{Provider: "pip", Name: name}
 	}
+	if err != nil {
+		return manager.Package{}, fmt.Errorf("pypi lookup failed: %v", err)
+	}
+	pkg.Score = 1
+	return pkg, nil
+}
 
-	// Parse the output into a Package struct
-	pkg := manager.Package{
-		Name:     query,
-		Provider: "pip",
-		Score:    0.8, // Default score for exact matches
+// GetInfo returns the metadata of a project from the PyPI JSON API with every
+// published release and the runtime requirements of the latest one
+func (p *PIPManager) GetInfo(ctx context.Context, name string) (manager.PackageInfo, error) {
+	info, err := p.index.projectInfo(ctx, name)
+	if err == errNotFound {
+		return manager.PackageInfo{}, &manager.NotFoundError{Provider: "pip", Name: name}
 	}
+	if err != nil {
+		return manager.PackageInfo{}, fmt.Errorf("pypi info lookup failed: %v", err)
+	}
+	return info, nil
+}
 
-	// Parse the output line by line
-	lines := strings.Split(string(output), "\n")
-	for _, line := range lines {
-		parts := strings.SplitN(line, ":", 2)
-		if len(parts) != 2 {
-			continue
+// Resolve pins a spec to a release using the PyPI JSON API. The constraint
+// may be empty (latest), an exact version or a range, which resolves to the
+// highest published version it allows.
+func (p *PIPManager) Resolve(ctx context.Context, spec manager.PackageSpec) (manager.ResolvedPackage, error) {
+	version, ok := spec.ExactVersion()
+	if spec.Constraint != "" && !ok {
+		var err error
+		if version, err = p.resolveRange(ctx, spec); err != nil {
+			return manager.ResolvedPackage{}, err
 		}
-		key := strings.TrimSpace(parts[0])
-		value := strings.TrimSpace(parts[1])
-
-		switch strings.ToLower(key) {
-		case "version":
-			pkg.Version = value
-		case "summary":
-			pkg.Description = value
-		case "author":
-			pkg.Author = value
-		case "home-page":
-			pkg.Homepage = value
+	}
+
+	release, err := p.index.release(ctx, spec.Name, version)
+	if err == errNotFound && version == "" {
+		return manager.ResolvedPackage{}, fmt.Errorf("%s is not published on the package index", spec.Name)
+	}
+	if err == errNotFound {
+		return manager.ResolvedPackage{}, fmt.Errorf("%s has no published version %s", spec.Name, version)
+	}
+	if err != nil {
+		return manager.ResolvedPackage{}, fmt.Errorf("pypi resolve failed: %v", err)
+	}
+
+	resolved := manager.ResolvedPackage{Name: spec.Name, Version: release.Info.Version}
+	for _, file := range release.URLs {
+		if file.Digests.SHA256 != "" {
+			resolved.Hashes = append(resolved.Hashes, "sha256:"+file.Digests.SHA256)
 		}
 	}
+	return resolved, nil
+}
+
+// resolveRange returns the highest published version allowed by the range of spec
+func (p *PIPManager) resolveRange(ctx context.Context, spec manager.PackageSpec) (string, error) {
+	latest, err := p.index.release(ctx, spec.Name, "")
+	if err == errNotFound {
+		return "", fmt.Errorf("%s is not published on the package index", spec.Name)
+	}
+	if err != nil {
+		return "", fmt.Errorf("pypi resolve failed: %v", err)
+	}
 
-	return []manager.Package{pkg}, nil
+	published := make([]string, 0, len(latest.Releases))
+	for v, files := range latest.Releases {
+		// Skip versions whose files were all deleted
+		if string(files) != "[]" {
+			published = append(published, v)
+		}
+	}
+	version, ok, err := manager.MaxSatisfying(published, spec.Constraint)
+	if err != nil {
+		return "", fmt.Errorf("cannot resolve %s: %v", spec, err)
+	}
+	if !ok {
+		return "", fmt.Errorf("%s has no published version matching %s", spec.Name, spec.Constraint)
+	}
+	return version, nil
 }
 
-func (p *PIPManager) Update(pkg string) error {
+func (p *PIPManager) Update(ctx context.Context, pkg string) error {
 	args := []string{"install", "--upgrade"}
 	if pkg != "" {
 		args = append(args, pkg)
 	} else {
-		args = append(args, "-r", "requirements.txt")
+		outdated, err := p.ListOutdated(ctx)
+		if err != nil {
+			return err
+		}
+		if len(outdated) == 0 {
+			return nil
+		}
+		for _, pkg := range outdated {
+			args = append(args, pkg.Name)
+		}
 	}
 
-	cmd := exec.Command("pip", args...)
-	output, err := cmd.CombinedOutput()
+	output, err := p.exec.CombinedOutput(ctx, "pip", args...)
 	if err != nil {
 		return fmt.Errorf("pip update failed: %v\n%s", err, string(output))
 	}
 	return nil
 }
 
-func (p *PIPManager) Remove(pkg string) error {
-	cmd := exec.Command("pip", "uninstall", "-y", pkg)
-	output, err := cmd.CombinedOutput()
+func (p *PIPManager) Remove(ctx context.Context, pkg string) error {
+	output, err := p.exec.CombinedOutput(ctx, "pip", "uninstall", "-y", pkg)
 	if err != nil {
 		return fmt.Errorf("pip uninstall failed: %v\n%s", err, string(output))
 	}
 	return nil
 }
 
-func (p *PIPManager) IsAvailable() bool {
-	cmd := exec.Command("pip", "--version")
-	if err := cmd.Run(); err != nil {
+func (p *PIPManager) ListInstalled(ctx context.Context) ([]manager.Package, error) {
+	output, err := p.exec.Output(ctx, "pip", "list", "--format=json")
+	if err != nil {
+		return nil, fmt.Errorf("pip list failed: %v", err)
+	}
+
+	var entries []struct {
+		Name                    string `json:"name"`
+		Version                 string `json:"version"`
+		EditableProjectLocation string `json:"editable_project_location"`
+	}
+	if err := json.Unmarshal(output, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse pip list output: %v", err)
+	}
+
+	userPackages := p.userPackages(ctx)
+
+	packages := make([]manager.Package, 0, len(entries))
+	for _, e := range entries {
+		source := "pypi"
+		if e.EditableProjectLocation != "" {
+			source = e.EditableProjectLocation
+		}
+		scope := "site"
+		if userPackages[e.Name] {
+			scope = "user"
+		}
+		packages = append(packages, manager.Package{
+			Name:     e.Name,
+			Version:  e.Version,
+			Provider: "pip",
+			Source:   source,
+			Scope:    scope,
+		})
+	}
+
+	return packages, nil
+}
+
+// ListTopLevel lists the installed packages no other installed package
+// requires, leaving out the dependencies pip pulled in
+func (p *PIPManager) ListTopLevel(ctx context.Context) ([]manager.Package, error) {
+	output, err := p.exec.Output(ctx, "pip", "list", "--not-required", "--format=json")
+	if err != nil {
+		return nil, fmt.Errorf("pip list failed: %v", err)
+	}
+
+	var entries []struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal(output, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse pip list output: %v", err)
+	}
+
+	packages := make([]manager.Package, 0, len(entries))
+	for _, e := range entries {
+		packages = append(packages, manager.Package{Name: e.Name, Version: e.Version, Provider: "pip"})
+	}
+	return packages, nil
+}
+
+// userPackages returns the names of the packages installed in the user site
+// directory. pip refuses "--user" inside virtualenvs, where every package is
+// in the environment's site directory, so errors yield an empty set.
+func (p *PIPManager) userPackages(ctx context.Context) map[string]bool {
+	names := make(map[string]bool)
+
+	output, err := p.exec.Output(ctx, "pip", "list", "--user", "--format=json")
+	if err != nil {
+		return names
+	}
+
+	var entries []struct {
+		Name string `json:"name"`
+	}
+	if json.Unmarshal(output, &entries) != nil {
+		return names
+	}
+	for _, e := range entries {
+		names[e.Name] = true
+	}
+	return names
+}
+
+func (p *PIPManager) ListOutdated(ctx context.Context) ([]manager.OutdatedPackage, error) {
+	output, err := p.exec.Output(ctx, "pip", "list", "--outdated", "--format=json")
+	if err != nil {
+		return nil, fmt.Errorf("pip list failed: %v", err)
+	}
+
+	var entries []struct {
+		Name          string `json:"name"`
+		Version       string `json:"version"`
+		LatestVersion string `json:"latest_version"`
+	}
+	if err := json.Unmarshal(output, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse pip list output: %v", err)
+	}
+
+	packages := make([]manager.OutdatedPackage, 0, len(entries))
+	for _, e := range entries {
+		// pip has no notion of a wanted version for installed packages
+		packages = append(packages, manager.OutdatedPackage{
+			Name:     e.Name,
+			Provider: "pip",
+			Current:  e.Version,
+			Wanted:   e.LatestVersion,
+			Latest:   e.LatestVersion,
+		})
+	}
+
+	return packages, nil
+}
+
+func (p *PIPManager) IsInstalled(ctx context.Context, pkg string) bool {
+	if err := p.exec.Run(ctx, "pip", "show", pkg); err != nil {
+		return false
+	}
+	return true
+}
+
+func (p *PIPManager) IsAvailable(ctx context.Context) bool {
+	if err := p.exec.Run(ctx, "pip", "--version"); err != nil {
 		return false
 	}
 	return true
 }
+
+func (p *PIPManager) Version(ctx context.Context) (string, error) {
+	// pip prints e.g. "pip 24.0 from /usr/lib/python3/dist-packages/pip (python 3.12)"
+	output, err := p.exec.Output(ctx, "pip", "--version")
+	if err != nil {
+		return "", fmt.Errorf("pip --version failed: %v", err)
+	}
+	fields := strings.Fields(string(output))
+	if len(fields) < 2 {
+		return "", fmt.Errorf("unexpected pip --version output: %q", string(output))
+	}
+	return fields[1], nil
+}
@@ -0,0 +1,284 @@
+package pip
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/RichestHumanAlive/ppm_cli/pkg/executor/executortest"
+	"github.com/RichestHumanAlive/ppm_cli/pkg/manager"
+)
+
+func TestInstall(t *testing.T) {
+	fake := executortest.New().On("pip install requests", executortest.Response{})
+	if err := New(WithExecutor(fake)).Install(context.Background(), manager.PackageSpec{Name: "requests"}); err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+}
+
+func TestInstallFailure(t *testing.T) {
+	fake := executortest.New().
+		On("pip install nope", executortest.Response{Stderr: "ERROR: No matching distribution found for nope", ExitCode: 1})
+
+	err := New(WithExecutor(fake)).Install(context.Background(), manager.PackageSpec{Name: "nope"})
+	if err == nil {
+		t.Fatal("Install() error = nil, want error")
+	}
+	if !strings.Contains(err.Error(), "No matching distribution") {
+		t.Errorf("Install() error = %q, want pip output included", err)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	fake := executortest.New().On("pip install --upgrade requests", executortest.Response{})
+	if err := New(WithExecutor(fake)).Update(context.Background(), "requests"); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+}
+
+func TestUpdateAll(t *testing.T) {
+	fake := executortest.New().
+		On("pip list --outdated --format=json", executortest.Response{Stdout: `[
+			{"name":"requests","version":"2.30.0","latest_version":"2.31.0"},
+			{"name":"black","version":"23.1.0","latest_version":"24.4.2"}]`}).
+		On("pip install --upgrade requests black", executortest.Response{})
+
+	if err := New(WithExecutor(fake)).Update(context.Background(), ""); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if !fake.Called("pip install --upgrade requests black") {
+		t.Errorf("calls = %v, want outdated packages upgraded", fake.Calls())
+	}
+}
+
+func TestUpdateAllNothingOutdated(t *testing.T) {
+	fake := executortest.New().On("pip list --outdated --format=json", executortest.Response{Stdout: "[]"})
+
+	if err := New(WithExecutor(fake)).Update(context.Background(), ""); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if calls := fake.Calls(); len(calls) != 1 {
+		t.Errorf("calls = %v, want only the outdated query", calls)
+	}
+}
+
+func TestUpdateFailure(t *testing.T) {
+	tests := []struct {
+		name string
+		pkg  string
+		fake *executortest.Fake
+	}{
+		{
+			name: "upgrade fails",
+			pkg:  "requests",
+			fake: executortest.New().On("pip install --upgrade requests", executortest.Response{ExitCode: 1}),
+		},
+		{
+			name: "outdated query fails",
+			fake: executortest.New().On("pip list --outdated --format=json", executortest.Response{ExitCode: 2}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := New(WithExecutor(tt.fake)).Update(context.Background(), tt.pkg); err == nil {
+				t.Error("Update() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestRemove(t *testing.T) {
+	fake := executortest.New().On("pip uninstall -y requests", executortest.Response{})
+	if err := New(WithExecutor(fake)).Remove(context.Background(), "requests"); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+
+	fake = executortest.New().On("pip uninstall -y requests", executortest.Response{ExitCode: 1})
+	if err := New(WithExecutor(fake)).Remove(context.Background(), "requests"); err == nil {
+		t.Error("Remove() error = nil, want error")
+	}
+}
+
+func TestListInstalled(t *testing.T) {
+	fake := executortest.New().
+		On("pip list --format=json", executortest.Response{Stdout: `[
+			{"name":"requests","version":"2.31.0"},
+			{"name":"mylib","version":"0.1.0","editable_project_location":"/src/mylib"}]`})
+
+	pkgs, err := New(WithExecutor(fake)).ListInstalled(context.Background())
+	if err != nil {
+		t.Fatalf("ListInstalled() error = %v", err)
+	}
+	if len(pkgs) != 2 {
+		t.Fatalf("ListInstalled() returned %d packages, want 2", len(pkgs))
+	}
+	if pkgs[0].Name != "requests" || pkgs[0].Version != "2.31.0" || pkgs[0].Source != "pypi" {
+		t.Errorf("ListInstalled()[0] = %+v", pkgs[0])
+	}
+	if pkgs[1].Source != "/src/mylib" {
+		t.Errorf("ListInstalled()[1].Source = %q, want editable location", pkgs[1].Source)
+	}
+}
+
+func TestListInstalledFailure(t *testing.T) {
+	tests := []struct {
+		name string
+		resp executortest.Response
+	}{
+		{"command fails", executortest.Response{ExitCode: 1}},
+		{"invalid json", executortest.Response{Stdout: "Package Version"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := executortest.New().On("pip list --format=json", tt.resp)
+			if _, err := New(WithExecutor(fake)).ListInstalled(context.Background()); err == nil {
+				t.Error("ListInstalled() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestListTopLevel(t *testing.T) {
+	fake := executortest.New().
+		On("pip list --not-required --format=json", executortest.Response{Stdout: `[{"name":"black","version":"24.4.2"},{"name":"pip","version":"24.0"}]`})
+
+	pkgs, err := New(WithExecutor(fake)).ListTopLevel(context.Background())
+	if err != nil {
+		t.Fatalf("ListTopLevel() error = %v", err)
+	}
+	if len(pkgs) != 2 || pkgs[0].Name != "black" || pkgs[0].Version != "24.4.2" || pkgs[0].Provider != "pip" {
+		t.Errorf("ListTopLevel() = %+v", pkgs)
+	}
+}
+
+func TestListOutdated(t *testing.T) {
+	fake := executortest.New().
+		On("pip list --outdated --format=json", executortest.Response{Stdout: `[{"name":"requests","version":"2.30.0","latest_version":"2.31.0"}]`})
+
+	pkgs, err := New(WithExecutor(fake)).ListOutdated(context.Background())
+	if err != nil {
+		t.Fatalf("ListOutdated() error = %v", err)
+	}
+	if len(pkgs) != 1 {
+		t.Fatalf("ListOutdated() returned %d packages, want 1", len(pkgs))
+	}
+	if pkg := pkgs[0]; pkg.Current != "2.30.0" || pkg.Wanted != "2.31.0" || pkg.Latest != "2.31.0" || pkg.Provider != "pip" {
+		t.Errorf("ListOutdated() = %+v", pkg)
+	}
+}
+
+func TestListOutdatedFailure(t *testing.T) {
+	fake := executortest.New().On("pip list --outdated --format=json", executortest.Response{Stdout: "{"})
+	if _, err := New(WithExecutor(fake)).ListOutdated(context.Background()); err == nil {
+		t.Error("ListOutdated() error = nil, want error")
+	}
+}
+
+func TestIsInstalled(t *testing.T) {
+	fake := executortest.New().
+		On("pip show requests", executortest.Response{Stdout: "Name: requests"}).
+		On("pip show missing", executortest.Response{ExitCode: 1})
+
+	p := New(WithExecutor(fake))
+	if !p.IsInstalled(context.Background(), "requests") {
+		t.Error("IsInstalled(requests) = false, want true")
+	}
+	if p.IsInstalled(context.Background(), "missing") {
+		t.Error("IsInstalled(missing) = true, want false")
+	}
+}
+
+func TestIsAvailable(t *testing.T) {
+	fake := executortest.New().On("pip --version", executortest.Response{Stdout: "pip 24.0"})
+	if !New(WithExecutor(fake)).IsAvailable(context.Background()) {
+		t.Error("IsAvailable() = false, want true")
+	}
+
+	fake = executortest.New().On("pip --version", executortest.Response{ExitCode: 127})
+	if New(WithExecutor(fake)).IsAvailable(context.Background()) {
+		t.Error("IsAvailable() = true, want false")
+	}
+}
+
+func TestInstallWithConstraint(t *testing.T) {
+	fake := executortest.New().On("pip install requests[socks]>=2.31", executortest.Response{})
+
+	spec := manager.PackageSpec{Name: "requests", Extras: []string{"socks"}, Constraint: ">=2.31"}
+	if err := New(WithExecutor(fake)).Install(context.Background(), spec); err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+}
+
+func TestInstallRejectsUnsupportedConstraint(t *testing.T) {
+	fake := executortest.New()
+
+	spec := manager.PackageSpec{Name: "requests", Constraint: "^2.31"}
+	if err := New(WithExecutor(fake)).Install(context.Background(), spec); err == nil {
+		t.Fatal("Install() error = nil, want error")
+	}
+	if calls := fake.Calls(); len(calls) != 0 {
+		t.Errorf("calls = %v, want pip not to run", calls)
+	}
+}
+
+func TestFormatSpec(t *testing.T) {
+	tests := []struct {
+		spec    manager.PackageSpec
+		want    string
+		wantErr bool
+	}{
+		{spec: manager.PackageSpec{Name: "requests"}, want: "requests"},
+		{spec: manager.PackageSpec{Name: "requests", Constraint: "2.31.0"}, want: "requests==2.31.0"},
+		{spec: manager.PackageSpec{Name: "requests", Constraint: "=2.31.0"}, want: "requests==2.31.0"},
+		{spec: manager.PackageSpec{Name: "black", Constraint: "23"}, want: "black==23.*"},
+		{spec: manager.PackageSpec{Name: "black", Constraint: "23.1"}, want: "black==23.1.*"},
+		{spec: manager.PackageSpec{Name: "requests", Constraint: ">=2.31,<3"}, want: "requests>=2.31,<3"},
+		{spec: manager.PackageSpec{Name: "requests", Constraint: "~=2.31"}, want: "requests~=2.31"},
+		{spec: manager.PackageSpec{Name: "requests", Extras: []string{"socks", "security"}}, want: "requests[socks,security]"},
+		{spec: manager.PackageSpec{Name: "requests", Constraint: "^2.31"}, wantErr: true},
+		{spec: manager.PackageSpec{Name: "requests", Constraint: "~2.31"}, wantErr: true},
+		{spec: manager.PackageSpec{Name: "requests", Constraint: "2.x"}, wantErr: true},
+		{spec: manager.PackageSpec{Name: "requests", Constraint: "1 || 2"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec.String(), func(t *testing.T) {
+			got, err := FormatSpec(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FormatSpec() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("FormatSpec() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListInstalledScope(t *testing.T) {
+	fake := executortest.New().
+		On("pip list --format=json", executortest.Response{Stdout: `[{"name":"requests","version":"2.31.0"},{"name":"black","version":"24.4.2"}]`}).
+		On("pip list --user --format=json", executortest.Response{Stdout: `[{"name":"black","version":"24.4.2"}]`})
+
+	pkgs, err := New(WithExecutor(fake)).ListInstalled(context.Background())
+	if err != nil {
+		t.Fatalf("ListInstalled() error = %v", err)
+	}
+	if pkgs[0].Scope != "site" || pkgs[1].Scope != "user" {
+		t.Errorf("ListInstalled() scopes = %q, %q, want site, user", pkgs[0].Scope, pkgs[1].Scope)
+	}
+}
+
+func TestVersion(t *testing.T) {
+	fake := executortest.New().On("pip --version", executortest.Response{Stdout: "pip 24.0 from /usr/lib/python3/dist-packages/pip (python 3.12)\n"})
+	version, err := New(WithExecutor(fake)).Version(context.Background())
+	if err != nil || version != "24.0" {
+		t.Errorf("Version() = %q, %v, want 24.0", version, err)
+	}
+
+	fake = executortest.New().On("pip --version", executortest.Response{Stdout: "\n"})
+	if _, err := New(WithExecutor(fake)).Version(context.Background()); err == nil {
+		t.Error("Version() error = nil, want error")
+	}
+}
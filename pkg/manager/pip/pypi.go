@@ -0,0 +1,478 @@
+package pip
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/RichestHumanAlive/ppm_cli/pkg/manager"
+)
+
+// DefaultIndexURL is the package index searched unless WithIndexURL says otherwise
+const DefaultIndexURL = "https://pypi.org"
+
+const (
+	// maxSearchResults caps how many fuzzy matches a search returns
+	maxSearchResults = 20
+
+	// maxDetailLookups caps how many matches get their metadata fetched from
+	// the JSON API; the rest are returned with their name only
+	maxDetailLookups = 10
+
+	// simpleJSONType is the PEP 691 JSON flavour of the Simple API
+	simpleJSONType = "application/vnd.pypi.simple.v1+json"
+
+	// ListingTTL is how long a Simple index listing kept with WithCacheDir
+	// is used before it is fetched again
+	ListingTTL = 24 * time.Hour
+)
+
+// PyPIResponse is the subset of the PyPI JSON API (/pypi/<name>/json) PPM reads
+type PyPIResponse struct {
+	Info struct {
+		Name              string            `json:"name"`
+		Version           string            `json:"version"`
+		Summary           string            `json:"summary"`
+		Author            string            `json:"author"`
+		Maintainer        string            `json:"maintainer"`
+		License           string            `json:"license"`
+		LicenseExpression string            `json:"license_expression"`
+		HomePage          string            `json:"home_page"`
+		ProjectURL        string            `json:"project_url"`
+		ProjectURLs       map[string]string `json:"project_urls"`
+		DownloadURL       string            `json:"download_url"`
+		RequiresDist      []string          `json:"requires_dist"`
+	} `json:"info"`
+	URLs     []pypiFile                 `json:"urls"`     // Files of the release the response describes
+	Releases map[string]json.RawMessage `json:"releases"` // Published versions, only sent for the latest release
+}
+
+// pypiFile is a distribution file of a release in the JSON API
+type pypiFile struct {
+	Filename string `json:"filename"`
+	Digests  struct {
+		SHA256 string `json:"sha256"`
+	} `json:"digests"`
+	UploadTime string `json:"upload_time_iso_8601"`
+}
+
+// uploadDate returns the date the first of a release's files was uploaded as
+// manager.ReleaseDateFormat, or "" when no file has an upload time
+func uploadDate(files []pypiFile) string {
+	var date string
+	for _, file := range files {
+		t, err := time.Parse(time.RFC3339, file.UploadTime)
+		if err != nil {
+			continue
+		}
+		if d := t.UTC().Format(manager.ReleaseDateFormat); date == "" || d < date {
+			date = d
+		}
+	}
+	return date
+}
+
+// simpleIndex is the PEP 691 JSON project listing served at /simple/
+type simpleIndex struct {
+	Projects []struct {
+		Name string `json:"name"`
+	} `json:"projects"`
+}
+
+// errNotFound is returned by the JSON API client for unknown projects
+var errNotFound = fmt.Errorf("package not found")
+
+// simpleAnchor matches the project links of the PEP 503 HTML listing
+var simpleAnchor = regexp.MustCompile(`(?i)<a\s[^>]*>([^<]+)</a>`)
+
+// normalizeRun matches the separators PEP 503 folds into a single "-"
+var normalizeRun = regexp.MustCompile(`[-_.]+`)
+
+// NormalizeName returns the PEP 503 normalized form of a project name
+func NormalizeName(name string) string {
+	return strings.ToLower(normalizeRun.ReplaceAllString(name, "-"))
+}
+
+// pypiClient talks to a PyPI compatible index over HTTP
+type pypiClient struct {
+	baseURL string
+	http    *http.Client
+
+	cacheDir string // where listings are kept between runs, "" for nowhere
+
+	mu       sync.Mutex
+	projects []string // cached Simple index listing
+}
+
+// normalizeIndexURL accepts either the index root ("https://pypi.org") or a
+// pip style Simple index URL ("https://pypi.org/simple/") and returns the root
+func normalizeIndexURL(indexURL string) string {
+	indexURL = strings.TrimRight(strings.TrimSpace(indexURL), "/")
+	return strings.TrimSuffix(indexURL, "/simple")
+}
+
+// get fetches path below the index root and returns the response body
+func (c *pypiClient) get(ctx context.Context, path, accept string) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, "", errNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("GET %s: %s", req.URL, resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	return body, resp.Header.Get("Content-Type"), nil
+}
+
+// release fetches a release of a project from the JSON API: the given
+// version, or the latest one when version is empty
+func (c *pypiClient) release(ctx context.Context, name, version string) (*PyPIResponse, error) {
+	path := "/pypi/" + url.PathEscape(name)
+	if version != "" {
+		path += "/" + url.PathEscape(version)
+	}
+
+	body, _, err := c.get(ctx, path+"/json", "application/json")
+	if err != nil {
+		return nil, err
+	}
+
+	var resp PyPIResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse PyPI response for %s: %v", name, err)
+	}
+	return &resp, nil
+}
+
+// project fetches the metadata of a single project from the JSON API
+func (c *pypiClient) project(ctx context.Context, name string) (manager.Package, error) {
+	resp, err := c.release(ctx, name, "")
+	if err != nil {
+		return manager.Package{}, err
+	}
+	return resp.latestPackage(name), nil
+}
+
+// latestPackage converts the release metadata of a project into a package
+func (resp *PyPIResponse) latestPackage(name string) manager.Package {
+	info := resp.Info
+	pkg := manager.Package{
+		Name:        info.Name,
+		Version:     info.Version,
+		Description: info.Summary,
+		Author:      info.Author,
+		Provider:    "pip",
+		Homepage:    info.HomePage,
+		License:     info.LicenseExpression,
+		Updated:     uploadDate(resp.URLs),
+	}
+	for label, link := range info.ProjectURLs {
+		switch strings.ToLower(label) {
+		case "homepage", "home":
+			if pkg.Homepage == "" {
+				pkg.Homepage = link
+			}
+		case "source", "source code", "repository", "code":
+			pkg.Repository = link
+		}
+	}
+	if pkg.Homepage == "" {
+		pkg.Homepage = info.ProjectURL
+	}
+	if pkg.Name == "" {
+		pkg.Name = name
+	}
+	// The license field often holds the whole license text; only keep
+	// short identifiers such as "MIT" or "Apache 2.0"
+	if pkg.License == "" && !strings.Contains(info.License, "\n") && len(info.License) <= 40 {
+		pkg.License = info.License
+	}
+	if info.Maintainer != "" {
+		pkg.Maintainers = []string{info.Maintainer}
+	}
+
+	return pkg
+}
+
+// projectInfo fetches the metadata of a project with its published releases
+// and the runtime requirements of its latest release
+func (c *pypiClient) projectInfo(ctx context.Context, name string) (manager.PackageInfo, error) {
+	resp, err := c.release(ctx, name, "")
+	if err != nil {
+		return manager.PackageInfo{}, err
+	}
+
+	info := manager.PackageInfo{
+		Package:  resp.latestPackage(name),
+		Releases: make([]manager.Release, 0, len(resp.Releases)),
+	}
+
+	for version, raw := range resp.Releases {
+		var files []pypiFile
+		if err := json.Unmarshal(raw, &files); err != nil || len(files) == 0 {
+			// Skip versions whose files were all deleted
+			continue
+		}
+		info.Releases = append(info.Releases, manager.Release{Version: version, Date: uploadDate(files)})
+	}
+	manager.SortReleases(info.Releases)
+
+	for _, req := range resp.Info.RequiresDist {
+		if spec, ok := requirementSpec(req); ok {
+			info.Dependencies = append(info.Dependencies, spec)
+		}
+	}
+	return info, nil
+}
+
+// requirementSpec turns a Requires-Dist entry such as
+// "charset-normalizer (<4,>=2)" into a spec, "charset-normalizer<4,>=2".
+// Requirements that only apply to an extra are skipped.
+func requirementSpec(req string) (string, bool) {
+	req, marker, _ := strings.Cut(req, ";")
+	if strings.Contains(marker, "extra") {
+		return "", false
+	}
+	req = strings.NewReplacer("(", "", ")", "", " ", "").Replace(req)
+	return req, req != ""
+}
+
+// listProjects returns every project name of the Simple index. The listing is
+// large and rarely changes, so it is fetched once per client and, with a cache
+// directory, once per ListingTTL.
+func (c *pypiClient) listProjects(ctx context.Context) ([]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.projects != nil {
+		return c.projects, nil
+	}
+	if names, ok := c.readListing(); ok {
+		c.projects = names
+		return names, nil
+	}
+
+	body, contentType, err := c.get(ctx, "/simple/", simpleJSONType+", text/html;q=0.1")
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	if strings.HasPrefix(contentType, simpleJSONType) || strings.Contains(contentType, "json") {
+		var index simpleIndex
+		if err := json.Unmarshal(body, &index); err != nil {
+			return nil, fmt.Errorf("failed to parse Simple index: %v", err)
+		}
+		names = make([]string, 0, len(index.Projects))
+		for _, p := range index.Projects {
+			names = append(names, p.Name)
+		}
+	} else {
+		matches := simpleAnchor.FindAllSubmatch(body, -1)
+		names = make([]string, 0, len(matches))
+		for _, m := range matches {
+			names = append(names, strings.TrimSpace(string(m[1])))
+		}
+	}
+
+	c.projects = names
+	c.writeListing(names)
+	return names, nil
+}
+
+// listingPath returns the file the index's listing is cached in, one per
+// index URL
+func (c *pypiClient) listingPath() string {
+	sum := sha256.Sum256([]byte(c.baseURL))
+	return filepath.Join(c.cacheDir, "pypi-simple-"+hex.EncodeToString(sum[:8])+".json")
+}
+
+// readListing returns the cached listing unless there is none or it is older
+// than ListingTTL
+func (c *pypiClient) readListing() ([]string, bool) {
+	if c.cacheDir == "" {
+		return nil, false
+	}
+	path := c.listingPath()
+	stat, err := os.Stat(path)
+	if err != nil || time.Since(stat.ModTime()) > ListingTTL {
+		return nil, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return nil, false
+	}
+	return names, true
+}
+
+// writeListing caches a listing for later runs. Failing to is not an error:
+// the listing is fetched again next time.
+func (c *pypiClient) writeListing(names []string) {
+	if c.cacheDir == "" {
+		return
+	}
+	data, err := json.Marshal(names)
+	if err != nil || os.MkdirAll(c.cacheDir, 0o755) != nil {
+		return
+	}
+	// Write to a temporary file first so concurrent runs never read a
+	// partial listing
+	tmp, err := os.CreateTemp(c.cacheDir, "pypi-simple-*.tmp")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil || os.Rename(tmp.Name(), c.listingPath()) != nil {
+		os.Remove(tmp.Name())
+	}
+}
+
+// match is a project name that fuzzily matches a search query
+type match struct {
+	name   string
+	score  float64
+	listed bool // whether the name came from the Simple index listing
+}
+
+// fuzzyMatch scores how well a project name matches a normalized query with
+// manager.MatchScore, adding a lowest tier for names that merely contain the
+// query's characters in order. A score of zero means no match.
+func fuzzyMatch(query, name string) float64 {
+	norm := NormalizeName(name)
+	if score := manager.MatchScore(query, norm); score > 0 {
+		return score
+	}
+	if norm != "" && isSubsequence(query, norm) {
+		return 0.1 + 0.2*float64(len(query))/float64(len(norm))
+	}
+	return 0
+}
+
+// isSubsequence reports whether the characters of sub appear in s in order
+func isSubsequence(sub, s string) bool {
+	i := 0
+	for j := 0; i < len(sub) && j < len(s); j++ {
+		if sub[i] == s[j] {
+			i++
+		}
+	}
+	return i == len(sub)
+}
+
+// search looks query up as an exact project name and fuzzily among the
+// Simple index listing, returning the best matches with their metadata
+func (c *pypiClient) search(ctx context.Context, query string) ([]manager.Package, error) {
+	query = NormalizeName(strings.TrimSpace(query))
+	if query == "" {
+		return []manager.Package{}, nil
+	}
+
+	// An index without a listing can still answer exact lookups
+	projects, listErr := c.listProjects(ctx)
+	if listErr == errNotFound {
+		listErr = nil
+	}
+
+	matches := make([]match, 0)
+	for _, name := range projects {
+		if score := fuzzyMatch(query, name); score > 0 {
+			matches = append(matches, match{name: name, score: score, listed: true})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		return matches[i].name < matches[j].name
+	})
+
+	// The exact name is always looked up so a search still finds it when the
+	// listing is unavailable
+	if len(matches) == 0 || matches[0].score < 1 {
+		matches = append([]match{{name: query, score: 1}}, matches...)
+	}
+	if len(matches) > maxSearchResults {
+		matches = matches[:maxSearchResults]
+	}
+
+	packages := make([]*manager.Package, len(matches))
+	errs := make([]error, len(matches))
+
+	var wg sync.WaitGroup
+	for i, m := range matches {
+		if i >= maxDetailLookups {
+			packages[i] = &manager.Package{Name: m.name, Provider: "pip", Score: m.score}
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, m match) {
+			defer wg.Done()
+			pkg, err := c.project(ctx, m.name)
+			switch {
+			case err == errNotFound && m.listed:
+				// Listed projects without any release have no metadata
+				packages[i] = &manager.Package{Name: m.name, Provider: "pip", Score: m.score}
+			case err == errNotFound:
+				// The guessed exact name does not exist
+			case err != nil:
+				errs[i] = err
+			default:
+				pkg.Score = m.score
+				packages[i] = &pkg
+			}
+		}(i, m)
+	}
+	wg.Wait()
+
+	results := make([]manager.Package, 0, len(matches))
+	firstErr := listErr
+	for i := range matches {
+		if packages[i] != nil {
+			results = append(results, *packages[i])
+		} else if errs[i] != nil && firstErr == nil {
+			firstErr = errs[i]
+		}
+	}
+
+	if len(results) == 0 && firstErr != nil {
+		return nil, firstErr
+	}
+	return results, nil
+}
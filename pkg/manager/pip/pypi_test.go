@@ -0,0 +1,367 @@
+package pip
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RichestHumanAlive/ppm_cli/pkg/manager"
+)
+
+// newIndex starts a stand-in package index serving the JSON API for the given
+// projects and a Simple listing in the given format ("json", "html" or "" for
+// none). With "fail", fetching the listing fails the test.
+func newIndex(t *testing.T, listing string, projects map[string]string) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/pypi/", func(w http.ResponseWriter, r *http.Request) {
+		name, version, _ := strings.Cut(strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/pypi/"), "/json"), "/")
+		body, ok := projects[NormalizeName(name)]
+		if version != "" && !strings.Contains(body, `"version":"`+version+`"`) {
+			ok = false
+		}
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	})
+	mux.HandleFunc("/simple/", func(w http.ResponseWriter, r *http.Request) {
+		switch listing {
+		case "json":
+			if !strings.Contains(r.Header.Get("Accept"), simpleJSONType) {
+				t.Errorf("Accept = %q, want %s", r.Header.Get("Accept"), simpleJSONType)
+			}
+			w.Header().Set("Content-Type", simpleJSONType)
+			w.Write([]byte(`{"meta":{"api-version":"1.1"},"projects":[
+				{"name":"requests"},{"name":"requests-oauthlib"},{"name":"types-requests"},
+				{"name":"flask"},{"name":"empty-project"}]}`))
+		case "html":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(`<!DOCTYPE html><html><body>
+<a href="/simple/requests/">requests</a>
+<a href="/simple/requests-oauthlib/">requests-oauthlib</a>
+<a href="/simple/flask/">flask</a>
+</body></html>`))
+		case "fail":
+			t.Errorf("GET %s, want no listing fetched", r.URL.Path)
+			http.NotFound(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+var testProjects = map[string]string{
+	"requests": `{"info":{"name":"requests","version":"2.31.0","summary":"Python HTTP for Humans.",
+		"author":"Kenneth Reitz","home_page":"https://requests.readthedocs.io","license":"Apache 2.0",
+		"project_urls":{"Source":"https://github.com/psf/requests"},
+		"requires_dist":["charset-normalizer (<4,>=2)","idna<4,>=2.5","PySocks!=1.5.7,>=1.5.6; extra == \"socks\""]},
+		"urls":[
+			{"filename":"requests-2.31.0-py3-none-any.whl","upload_time_iso_8601":"2023-05-22T15:12:44.175068Z","digests":{"sha256":"58cd2187c01e70e6e26505bca751777aa9f2ee0b7f4300988b709f44e013003f"}},
+			{"filename":"requests-2.31.0.tar.gz","digests":{"sha256":"942c5a758f98d790eaed1a29cb6eefc7ffb0d1cf7af05c3d2791656dbd6ad1e1"}}],
+		"releases":{"2.30.0":[{}],"2.32.0":[],"3.0.0b1":[{}],"2.31.0":[
+			{"upload_time_iso_8601":"2023-05-22T15:12:44.175068Z"},
+			{"upload_time_iso_8601":"2023-05-22T15:12:42.313790Z"}]}}`,
+	"requests-oauthlib": `{"info":{"name":"requests-oauthlib","version":"2.0.0","summary":"OAuthlib authentication support for Requests."}}`,
+	"types-requests":    `{"info":{"name":"types-requests","version":"2.32.0","summary":"Typing stubs for requests"}}`,
+	"black": `{"info":{"name":"black","version":"24.4.2"},
+		"urls":[{"filename":"black-24.4.2.tar.gz","digests":{"sha256":"c872b53057f000085da66a19c55d68f6f8ddcac2642392ad3a355878406fbd4d"}}],
+		"releases":{"22.12.0":[{}],"23.1.0":[{}],"23.12.1":[{}],"24.4.2":[{}]}}`,
+	"flask": `{"info":{"name":"Flask","version":"3.0.3","summary":"A simple framework for building complex web applications.","project_urls":{"Homepage":"https://palletsprojects.com/p/flask"}}}`,
+}
+
+func TestSearchExact(t *testing.T) {
+	server := newIndex(t, "json", testProjects)
+
+	pkgs, err := New(WithIndexURL(server.URL)).Search(context.Background(), "requests")
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(pkgs) != 3 {
+		t.Fatalf("Search() returned %d packages, want 3: %+v", len(pkgs), pkgs)
+	}
+
+	pkg := pkgs[0]
+	if pkg.Name != "requests" || pkg.Version != "2.31.0" || pkg.Provider != "pip" || pkg.Score != 1 {
+		t.Errorf("Search()[0] = %+v", pkg)
+	}
+	if pkg.Description != "Python HTTP for Humans." || pkg.Author != "Kenneth Reitz" || pkg.Updated != "2023-05-22" {
+		t.Errorf("Search()[0] metadata = %+v", pkg)
+	}
+	if pkg.Homepage != "https://requests.readthedocs.io" || pkg.Repository != "https://github.com/psf/requests" {
+		t.Errorf("Search()[0] links = %+v", pkg)
+	}
+
+	// prefix matches rank above substring matches
+	if pkgs[1].Name != "requests-oauthlib" || pkgs[2].Name != "types-requests" {
+		t.Errorf("Search() order = %s, %s", pkgs[1].Name, pkgs[2].Name)
+	}
+	if pkgs[1].Score <= pkgs[2].Score || pkgs[0].Score <= pkgs[1].Score {
+		t.Errorf("Search() scores = %v, %v, %v, want descending", pkgs[0].Score, pkgs[1].Score, pkgs[2].Score)
+	}
+}
+
+func TestSearchPartial(t *testing.T) {
+	tests := []struct {
+		listing string
+		want    []string
+	}{
+		{"json", []string{"requests", "requests-oauthlib"}},
+		{"html", []string{"requests", "requests-oauthlib"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.listing, func(t *testing.T) {
+			server := newIndex(t, tt.listing, testProjects)
+
+			pkgs, err := New(WithIndexURL(server.URL+"/simple/")).Search(context.Background(), "reque")
+			if err != nil {
+				t.Fatalf("Search() error = %v", err)
+			}
+			if len(pkgs) < len(tt.want) {
+				t.Fatalf("Search() = %+v, want at least %v", pkgs, tt.want)
+			}
+			for i, name := range tt.want {
+				if pkgs[i].Name != name {
+					t.Errorf("Search()[%d] = %s, want %s", i, pkgs[i].Name, name)
+				}
+			}
+			if pkgs[0].Version != "2.31.0" {
+				t.Errorf("Search()[0].Version = %q, want details from the JSON API", pkgs[0].Version)
+			}
+		})
+	}
+}
+
+func TestSearchNormalizesNames(t *testing.T) {
+	server := newIndex(t, "json", testProjects)
+
+	pkgs, err := New(WithIndexURL(server.URL)).Search(context.Background(), "Requests_OAuthlib")
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(pkgs) == 0 || pkgs[0].Name != "requests-oauthlib" || pkgs[0].Score != 1 {
+		t.Errorf("Search() = %+v, want requests-oauthlib as exact match", pkgs)
+	}
+}
+
+func TestSearchWithoutListing(t *testing.T) {
+	server := newIndex(t, "", testProjects)
+
+	pkgs, err := New(WithIndexURL(server.URL)).Search(context.Background(), "flask")
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(pkgs) != 1 || pkgs[0].Name != "Flask" || pkgs[0].Homepage != "https://palletsprojects.com/p/flask" {
+		t.Errorf("Search() = %+v, want Flask from the JSON API", pkgs)
+	}
+}
+
+func TestSearchListedWithoutMetadata(t *testing.T) {
+	server := newIndex(t, "json", testProjects)
+
+	pkgs, err := New(WithIndexURL(server.URL)).Search(context.Background(), "empty-project")
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(pkgs) != 1 || pkgs[0].Name != "empty-project" || pkgs[0].Version != "" {
+		t.Errorf("Search() = %+v, want the listed name without details", pkgs)
+	}
+}
+
+func TestSearchNotFound(t *testing.T) {
+	server := newIndex(t, "json", testProjects)
+
+	pkgs, err := New(WithIndexURL(server.URL)).Search(context.Background(), "zzzz")
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(pkgs) != 0 {
+		t.Errorf("Search() = %+v, want no results", pkgs)
+	}
+}
+
+func TestSearchFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	if _, err := New(WithIndexURL(server.URL)).Search(context.Background(), "requests"); err == nil {
+		t.Error("Search() error = nil, want error")
+	}
+}
+
+func TestSearchCancelled(t *testing.T) {
+	server := newIndex(t, "json", testProjects)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := New(WithIndexURL(server.URL)).Search(ctx, "requests"); err == nil {
+		t.Error("Search() error = nil, want error")
+	}
+}
+
+func TestSearchCachesListing(t *testing.T) {
+	server := newIndex(t, "json", testProjects)
+	dir := t.TempDir()
+
+	if _, err := New(WithIndexURL(server.URL), WithCacheDir(dir)).Search(context.Background(), "reque"); err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+
+	// Later runs read the cached listing rather than the index's
+	p := New(WithIndexURL(server.URL), WithCacheDir(dir))
+	path := p.index.listingPath()
+	if err := os.WriteFile(path, []byte(`["requests-toolbelt"]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	pkgs, err := p.Search(context.Background(), "reque")
+	if err != nil || len(pkgs) != 1 || pkgs[0].Name != "requests-toolbelt" {
+		t.Errorf("Search() = %+v, %v, want the cached listing", pkgs, err)
+	}
+
+	// Stale listings are fetched again
+	stale := time.Now().Add(-ListingTTL - time.Minute)
+	if err := os.Chtimes(path, stale, stale); err != nil {
+		t.Fatal(err)
+	}
+	pkgs, err = New(WithIndexURL(server.URL), WithCacheDir(dir)).Search(context.Background(), "reque")
+	if err != nil || len(pkgs) < 2 || pkgs[1].Name != "requests-oauthlib" {
+		t.Errorf("Search() = %+v, %v, want a fresh listing", pkgs, err)
+	}
+}
+
+func TestFindPackage(t *testing.T) {
+	server := newIndex(t, "fail", testProjects)
+	p := New(WithIndexURL(server.URL))
+
+	pkg, err := p.FindPackage(context.Background(), "Flask")
+	if err != nil || pkg.Name != "Flask" || pkg.Version != "3.0.3" || pkg.Score != 1 {
+		t.Errorf("FindPackage() = %+v, %v, want Flask from the JSON API", pkg, err)
+	}
+	if _, err := p.FindPackage(context.Background(), "missing"); err == nil {
+		t.Error("FindPackage(missing) error = nil, want *manager.NotFoundError")
+	} else if _, ok := err.(*manager.NotFoundError); !ok {
+		t.Errorf("FindPackage(missing) error = %v, want *manager.NotFoundError", err)
+	}
+}
+
+func TestNormalizeIndexURL(t *testing.T) {
+	tests := map[string]string{
+		"https://pypi.org":                       "https://pypi.org",
+		"https://pypi.org/":                      "https://pypi.org",
+		"https://pypi.org/simple/":               "https://pypi.org",
+		"http://localhost:3141/root/pypi/simple": "http://localhost:3141/root/pypi",
+	}
+
+	for in, want := range tests {
+		if got := normalizeIndexURL(in); got != want {
+			t.Errorf("normalizeIndexURL(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	server := newIndex(t, "json", testProjects)
+	p := New(WithIndexURL(server.URL))
+
+	for _, constraint := range []string{"", "2.31.0", "==2.31.0", ">=2", "~=2.30"} {
+		resolved, err := p.Resolve(context.Background(), manager.PackageSpec{Name: "requests", Constraint: constraint})
+		if err != nil {
+			t.Fatalf("Resolve(%q) error = %v", constraint, err)
+		}
+		if resolved.Version != "2.31.0" || len(resolved.Hashes) != 2 || !strings.HasPrefix(resolved.Hashes[0], "sha256:58cd") {
+			t.Errorf("Resolve(%q) = %+v", constraint, resolved)
+		}
+	}
+
+	tests := []manager.PackageSpec{
+		{Name: "requests", Constraint: "2.30.0"},
+		{Name: "requests", Constraint: ">=3"},
+		{Name: "requests", Constraint: "~=2"},
+		{Name: "missing"},
+	}
+	for _, spec := range tests {
+		if _, err := p.Resolve(context.Background(), spec); err == nil {
+			t.Errorf("Resolve(%s) error = nil, want error", spec)
+		}
+	}
+}
+
+func TestResolvePartialVersion(t *testing.T) {
+	releases := map[string]string{
+		"23.12.1": `{"info":{"name":"black","version":"23.12.1"},"urls":[{"digests":{"sha256":"4ce3ef14ebe8d9509188014d96af1c456a910d5b5cbf434a09fef7e024b3d0d5"}}]}`,
+		"23.1.0":  `{"info":{"name":"black","version":"23.1.0"},"urls":[{"digests":{"sha256":"09bf47fe1c4b3ba5cfbbf7ac3d9b6e8c6c0ff0e3b2c3e7e71c5e1fc05dfb1d09"}}]}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch path := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/pypi/black"), "/json"); path {
+		case "":
+			w.Write([]byte(testProjects["black"]))
+		default:
+			body, ok := releases[strings.TrimPrefix(path, "/")]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write([]byte(body))
+		}
+	}))
+	defer server.Close()
+	p := New(WithIndexURL(server.URL))
+
+	for constraint, want := range map[string]string{"23": "23.12.1", "23.1": "23.1.0"} {
+		resolved, err := p.Resolve(context.Background(), manager.PackageSpec{Name: "black", Constraint: constraint})
+		if err != nil || resolved.Version != want || len(resolved.Hashes) != 1 {
+			t.Errorf("Resolve(black@%s) = %+v, %v, want %s", constraint, resolved, err, want)
+		}
+	}
+}
+
+func TestGetInfo(t *testing.T) {
+	server := newIndex(t, "json", testProjects)
+	p := New(WithIndexURL(server.URL))
+
+	info, err := p.GetInfo(context.Background(), "requests")
+	if err != nil {
+		t.Fatalf("GetInfo() error = %v", err)
+	}
+	if info.Name != "requests" || info.Version != "2.31.0" || info.License != "Apache 2.0" || info.Repository != "https://github.com/psf/requests" {
+		t.Errorf("GetInfo() = %+v", info.Package)
+	}
+
+	want := []manager.Release{{Version: "3.0.0b1"}, {Version: "2.31.0", Date: "2023-05-22"}, {Version: "2.30.0"}}
+	if len(info.Releases) != len(want) {
+		t.Fatalf("GetInfo() releases = %+v, want %+v", info.Releases, want)
+	}
+	for i := range want {
+		if info.Releases[i] != want[i] {
+			t.Errorf("GetInfo() releases[%d] = %+v, want %+v", i, info.Releases[i], want[i])
+		}
+	}
+
+	deps := strings.Join(info.Dependencies, " ")
+	if deps != "charset-normalizer<4,>=2 idna<4,>=2.5" {
+		t.Errorf("GetInfo() dependencies = %q", deps)
+	}
+
+	if _, err := p.GetInfo(context.Background(), "missing"); !isNotFound(err) {
+		t.Errorf("GetInfo(missing) error = %v, want *manager.NotFoundError", err)
+	}
+}
+
+func isNotFound(err error) bool {
+	_, ok := err.(*manager.NotFoundError)
+	return ok
+}
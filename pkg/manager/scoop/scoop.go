@@ -1,133 +1,598 @@
 package scoop
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
-	"os/exec"
+	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 
+	"github.com/RichestHumanAlive/ppm_cli/pkg/executor"
 	"github.com/RichestHumanAlive/ppm_cli/pkg/manager"
 )
 
-type ScoopManager struct{}
+type ScoopManager struct {
+	exec executor.Executor
+	root string // Scoop installation directory holding the buckets
+}
+
+// Option configures a ScoopManager
+type Option func(*ScoopManager)
+
+// WithExecutor makes the manager run scoop through e instead of the system executor
+func WithExecutor(e executor.Executor) Option {
+	return func(m *ScoopManager) {
+		m.exec = e
+	}
+}
+
+// WithRoot makes the manager read buckets from the scoop installation at dir
+func WithRoot(dir string) Option {
+	return func(m *ScoopManager) {
+		m.root = dir
+	}
+}
 
-func New() *ScoopManager {
-	return &ScoopManager{}
+// defaultRoot returns the scoop installation directory: $SCOOP when set,
+// ~/scoop otherwise
+func defaultRoot() string {
+	if root := os.Getenv("SCOOP"); root != "" {
+		return root
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "scoop"
+	}
+	return filepath.Join(home, "scoop")
+}
+
+func New(opts ...Option) *ScoopManager {
+	m := &ScoopManager{exec: executor.System{}, root: defaultRoot()}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
 }
 
 func (s *ScoopManager) GetName() string {
 	return "scoop"
 }
 
-func (s *ScoopManager) Install(pkg string) error {
-	cmd := exec.Command("scoop", "install", pkg)
-	output, err := cmd.CombinedOutput()
+// SupportsOS reports whether scoop runs on goos; it is Windows only
+func (s *ScoopManager) SupportsOS(goos string) bool {
+	return goos == "windows"
+}
+
+// MaxConcurrency serializes scoop operations, which share the apps, shims
+// and cache directories of the scoop root
+func (s *ScoopManager) MaxConcurrency() int {
+	return 1
+}
+
+func (s *ScoopManager) Install(ctx context.Context, spec manager.PackageSpec) error {
+	pkg, err := FormatSpec(spec)
+	if err != nil {
+		return err
+	}
+
+	output, err := s.exec.CombinedOutput(ctx, "scoop", "install", pkg)
 	if err != nil {
 		return fmt.Errorf("scoop install failed: %v\n%s", err, string(output))
 	}
 	return nil
 }
 
+// FormatSpec translates a package spec into scoop's "app@version" syntax.
+// Scoop can only install an exact version, so ranges are rejected.
+func FormatSpec(spec manager.PackageSpec) (string, error) {
+	if len(spec.Extras) > 0 {
+		return "", fmt.Errorf("scoop does not support extras (%s)", spec)
+	}
+	if spec.Constraint == "" {
+		return spec.Name, nil
+	}
+
+	// Many apps version as major.minor ("7zip@24.07"), so partial versions
+	// are taken as written
+	version, ok := spec.SingleVersion()
+	if !ok {
+		return "", fmt.Errorf("scoop can only install exact versions, not %q", spec.Constraint)
+	}
+	return spec.Name + "@" + version, nil
+}
+
+// ScoopApp is the subset of a bucket manifest (<bucket>/bucket/<app>.json) PPM reads
 type ScoopApp struct {
-	Version     string `json:"version"`
-	Description string `json:"description"`
-	Homepage    string `json:"homepage"`
-	License     string `json:"license"`
+	Version      string                  `json:"version"`
+	Description  string                  `json:"description"`
+	Homepage     string                  `json:"homepage"`
+	License      string                  `json:"license"`
+	Hash         stringList              `json:"hash"`
+	Depends      stringList              `json:"depends"`      // Apps installed first, possibly bucket-qualified
+	Architecture map[string]ScoopAppArch `json:"architecture"` // Keyed by 64bit, 32bit or arm64
+}
+
+// ScoopAppArch holds the architecture specific parts of a manifest
+type ScoopAppArch struct {
+	Hash stringList `json:"hash"`
+}
+
+// stringList is a manifest field given either as one string or as a list, e.g.
+// the hashes of apps that download several files
+type stringList []string
+
+func (h *stringList) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*h = stringList{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*h = many
+	return nil
 }
 
-func (s *ScoopManager) Search(query string) ([]manager.Package, error) {
-	// First, update the scoop database
-	updateCmd := exec.Command("scoop", "update")
-	updateCmd.Run() // Ignore errors, just try to update
+// Hashes returns every download hash of the manifest, architectures in
+// sorted order, as "algorithm:hex". Scoop hashes without a prefix are sha256.
+func (a ScoopApp) Hashes() []string {
+	all := append([]string{}, a.Hash...)
 
-	// Search for the package
-	cmd := exec.Command("scoop", "search", query)
-	output, err := cmd.CombinedOutput()
+	archs := make([]string, 0, len(a.Architecture))
+	for arch := range a.Architecture {
+		archs = append(archs, arch)
+	}
+	sort.Strings(archs)
+	for _, arch := range archs {
+		all = append(all, a.Architecture[arch].Hash...)
+	}
+
+	for i, hash := range all {
+		if !strings.Contains(hash, ":") {
+			all[i] = "sha256:" + hash
+		}
+	}
+	return all
+}
+
+// UnmarshalJSON accepts licenses given either as an SPDX string or as an
+// {"identifier": ..., "url": ...} object
+func (a *ScoopApp) UnmarshalJSON(data []byte) error {
+	type plain ScoopApp
+	var manifest struct {
+		plain
+		License json.RawMessage `json:"license"`
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return err
+	}
+	*a = ScoopApp(manifest.plain)
+
+	if len(manifest.License) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(manifest.License, &a.License); err == nil {
+		return nil
+	}
+	var license struct {
+		Identifier string `json:"identifier"`
+	}
+	if err := json.Unmarshal(manifest.License, &license); err != nil {
+		return fmt.Errorf("invalid license: %v", err)
+	}
+	a.License = license.Identifier
+	return nil
+}
+
+// manifestDirs returns the manifest directory of every local bucket, keyed by
+// bucket name. Buckets keep manifests in a "bucket" subdirectory, older ones
+// at their top level.
+func (s *ScoopManager) manifestDirs() (map[string]string, error) {
+	entries, err := os.ReadDir(filepath.Join(s.root, "buckets"))
 	if err != nil {
-		return nil, fmt.Errorf("scoop search failed: %v", err)
+		return nil, fmt.Errorf("no scoop buckets found: %v", err)
 	}
 
-	// Parse the output
-	results := make([]manager.Package, 0)
-	lines := strings.Split(string(output), "\n")
-	
-	for _, line := range lines {
-		if strings.TrimSpace(line) == "" {
+	dirs := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() {
 			continue
 		}
-		
-		// Scoop output format is typically: 'name (bucket): description'
-		parts := strings.SplitN(line, ":", 2)
-		if len(parts) != 2 {
-			continue
+		dir := filepath.Join(s.root, "buckets", entry.Name())
+		if info, err := os.Stat(filepath.Join(dir, "bucket")); err == nil && info.IsDir() {
+			dir = filepath.Join(dir, "bucket")
 		}
-		
-		namePart := strings.TrimSpace(parts[0])
-		description := strings.TrimSpace(parts[1])
-		
-		// Extract name and bucket
-		name := namePart
-		if strings.Contains(namePart, " (") {
-			nameParts := strings.Split(strings.Trim(namePart, ")"), " (")
-			name = nameParts[0]
+		dirs[entry.Name()] = dir
+	}
+	return dirs, nil
+}
+
+// Search matches the query against the app names of the local buckets and
+// reads the matching manifests. Buckets are only as fresh as the last
+// "scoop update".
+func (s *ScoopManager) Search(ctx context.Context, query string) ([]manager.Package, error) {
+	dirs, err := s.manifestDirs()
+	if err != nil {
+		return nil, fmt.Errorf("scoop search failed: %v", err)
+	}
+
+	query = strings.ToLower(strings.TrimSpace(query))
+	results := make([]manager.Package, 0)
+
+	for bucket, dir := range dirs {
+		if err := ctx.Err(); err != nil {
+			return nil, err
 		}
 
-		// Get more details about the package
-		infoCmd := exec.Command("scoop", "info", name)
-		infoOutput, err := infoCmd.CombinedOutput()
+		files, err := os.ReadDir(dir)
 		if err != nil {
 			continue
 		}
+		for _, file := range files {
+			name, ok := strings.CutSuffix(file.Name(), ".json")
+			if !ok || file.IsDir() {
+				continue
+			}
+			score := manager.MatchScore(query, strings.ToLower(name))
+			if score == 0 {
+				continue
+			}
 
-		pkg := manager.Package{
-			Name:        name,
-			Description: description,
-			Provider:    "scoop",
-			Score:      0.7, // Default score
+			data, err := os.ReadFile(filepath.Join(dir, file.Name()))
+			if err != nil {
+				continue
+			}
+			var app ScoopApp
+			if err := json.Unmarshal(data, &app); err != nil {
+				// Skip broken manifests like scoop itself does
+				continue
+			}
+
+			results = append(results, manager.Package{
+				Name:        name,
+				Version:     app.Version,
+				Description: app.Description,
+				Provider:    "scoop",
+				Score:       score,
+				Homepage:    app.Homepage,
+				License:     app.License,
+				Source:      bucket,
+			})
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
 		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Source < b.Source
+	})
+
+	return results, nil
+}
+
+// findManifest reads the manifest of an app from the local buckets. The name
+// may be qualified with its bucket, as in "extras/vscode"; otherwise the main
+// bucket is preferred, then the others in alphabetical order. An app no bucket
+// has is a *manager.NotFoundError.
+func (s *ScoopManager) findManifest(name string) (string, ScoopApp, error) {
+	dirs, err := s.manifestDirs()
+	if err != nil {
+		return "", ScoopApp{}, err
+	}
 
-		// Parse the info output
-		infoLines := strings.Split(string(infoOutput), "\n")
-		for _, infoLine := range infoLines {
-			if strings.Contains(infoLine, "Version:") {
-				pkg.Version = strings.TrimSpace(strings.TrimPrefix(infoLine, "Version:"))
-			} else if strings.Contains(infoLine, "Website:") {
-				pkg.Homepage = strings.TrimSpace(strings.TrimPrefix(infoLine, "Website:"))
+	buckets := make([]string, 0, len(dirs))
+	if bucket, app, ok := strings.Cut(name, "/"); ok {
+		buckets, name = append(buckets, bucket), app
+	} else {
+		for bucket := range dirs {
+			buckets = append(buckets, bucket)
+		}
+		sort.Slice(buckets, func(i, j int) bool {
+			if (buckets[i] == "main") != (buckets[j] == "main") {
+				return buckets[i] == "main"
 			}
+			return buckets[i] < buckets[j]
+		})
+	}
+
+	for _, bucket := range buckets {
+		dir, ok := dirs[bucket]
+		if !ok {
+			continue
 		}
+		data, err := os.ReadFile(filepath.Join(dir, name+".json"))
+		if err != nil {
+			continue
+		}
+		var app ScoopApp
+		if err := json.Unmarshal(data, &app); err != nil {
+			return "", ScoopApp{}, fmt.Errorf("invalid manifest %s/%s: %v", bucket, name, err)
+		}
+		return bucket, app, nil
+	}
 
-		results = append(results, pkg)
+	return "", ScoopApp{}, &manager.NotFoundError{Provider: "scoop", Name: name}
+}
+
+// Resolve pins a spec to the version of its bucket manifest. Buckets only
+// describe their current version, so the constraint must allow that version.
+func (s *ScoopManager) Resolve(ctx context.Context, spec manager.PackageSpec) (manager.ResolvedPackage, error) {
+	_, app, err := s.findManifest(spec.Name)
+	if err != nil {
+		return manager.ResolvedPackage{}, fmt.Errorf("scoop resolve failed: %v", err)
 	}
 
-	return results, nil
+	if ok, err := manager.Satisfies(app.Version, spec.Constraint); err != nil || !ok {
+		return manager.ResolvedPackage{}, fmt.Errorf("cannot resolve %s: the local buckets only have version %s", spec, app.Version)
+	}
+
+	return manager.ResolvedPackage{Name: spec.Name, Version: app.Version, Hashes: app.Hashes()}, nil
 }
 
-func (s *ScoopManager) Update(pkg string) error {
-	args := []string{"update"}
+// GetInfo describes an app from its bucket manifest. Buckets only hold the
+// current version of an app, so it is the only release reported, without a
+// date.
+func (s *ScoopManager) GetInfo(ctx context.Context, name string) (manager.PackageInfo, error) {
+	bucket, app, err := s.findManifest(name)
+	if _, ok := err.(*manager.NotFoundError); ok {
+		return manager.PackageInfo{}, err
+	}
+	if err != nil {
+		return manager.PackageInfo{}, fmt.Errorf("scoop info lookup failed: %v", err)
+	}
+	if _, short, ok := strings.Cut(name, "/"); ok {
+		name = short
+	}
+
+	return manager.PackageInfo{
+		Package: manager.Package{
+			Name:        name,
+			Version:     app.Version,
+			Description: app.Description,
+			Provider:    "scoop",
+			Homepage:    app.Homepage,
+			License:     app.License,
+			Source:      bucket,
+		},
+		Releases:     []manager.Release{{Version: app.Version}},
+		Dependencies: app.Depends,
+	}, nil
+}
+
+func (s *ScoopManager) Update(ctx context.Context, pkg string) error {
+	// "scoop update" without an app only refreshes scoop and its buckets,
+	// so ask for every installed app explicitly
+	args := []string{"update", "*"}
 	if pkg != "" {
-		args = append(args, pkg)
+		args = []string{"update", pkg}
 	}
 
-	cmd := exec.Command("scoop", args...)
-	output, err := cmd.CombinedOutput()
+	output, err := s.exec.CombinedOutput(ctx, "scoop", args...)
 	if err != nil {
 		return fmt.Errorf("scoop update failed: %v\n%s", err, string(output))
 	}
 	return nil
 }
 
-func (s *ScoopManager) Remove(pkg string) error {
-	cmd := exec.Command("scoop", "uninstall", pkg)
-	output, err := cmd.CombinedOutput()
+func (s *ScoopManager) Remove(ctx context.Context, pkg string) error {
+	output, err := s.exec.CombinedOutput(ctx, "scoop", "uninstall", pkg)
 	if err != nil {
 		return fmt.Errorf("scoop uninstall failed: %v\n%s", err, string(output))
 	}
 	return nil
 }
 
-func (s *ScoopManager) IsAvailable() bool {
-	cmd := exec.Command("scoop", "--version")
-	if err := cmd.Run(); err != nil {
+type ScoopExport struct {
+	Apps []struct {
+		Name    string `json:"Name"`
+		Version string `json:"Version"`
+		Source  string `json:"Source"`
+		Info    string `json:"Info"`
+	} `json:"apps"`
+}
+
+func (s *ScoopManager) ListInstalled(ctx context.Context) ([]manager.Package, error) {
+	// Recent scoop versions export JSON; older ones only print a table,
+	// so fall back to parsing "scoop list" when the export is not JSON
+	output, err := s.exec.Output(ctx, "scoop", "export")
+	if err == nil {
+		var export ScoopExport
+		if json.Unmarshal(output, &export) == nil {
+			packages := make([]manager.Package, 0, len(export.Apps))
+			for _, app := range export.Apps {
+				packages = append(packages, manager.Package{
+					Name:     app.Name,
+					Version:  app.Version,
+					Provider: "scoop",
+					Source:   app.Source,
+					Scope:    installScope(app.Info),
+				})
+			}
+			return packages, nil
+		}
+	}
+
+	output, err = s.exec.Output(ctx, "scoop", "list")
+	if err != nil {
+		return nil, fmt.Errorf("scoop list failed: %v", err)
+	}
+
+	return parseScoopList(string(output)), nil
+}
+
+// ListTopLevel lists the installed apps no other installed app depends on,
+// going by the depends field of their bucket manifests. Apps whose manifest
+// cannot be found, e.g. ones installed from a URL, depend on nothing.
+func (s *ScoopManager) ListTopLevel(ctx context.Context) ([]manager.Package, error) {
+	installed, err := s.ListInstalled(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dependencies := make(map[string]bool)
+	for _, pkg := range installed {
+		name := pkg.Name
+		if pkg.Source != "" {
+			name = pkg.Source + "/" + pkg.Name
+		}
+		_, app, err := s.findManifest(name)
+		if err != nil {
+			continue
+		}
+		for _, dep := range app.Depends {
+			// Dependencies may be bucket-qualified, as in "extras/vcredist2022"
+			dependencies[strings.ToLower(dep[strings.LastIndex(dep, "/")+1:])] = true
+		}
+	}
+
+	topLevel := make([]manager.Package, 0, len(installed))
+	for _, pkg := range installed {
+		if !dependencies[strings.ToLower(pkg.Name)] {
+			topLevel = append(topLevel, pkg)
+		}
+	}
+	return topLevel, nil
+}
+
+// installScope returns "global" for apps scoop marks as "Global install" in
+// their info column and "user" otherwise
+func installScope(info string) string {
+	if strings.Contains(info, "Global install") {
+		return "global"
+	}
+	return "user"
+}
+
+// parseScoopList parses the table printed by "scoop list":
+//
+//	Name Version Source Updated             Info
+//	---- ------- ------ -------             ----
+//	7zip 23.01   main   2023-07-01 10:00:00
+func parseScoopList(output string) []manager.Package {
+	packages := make([]manager.Package, 0)
+	inTable := false
+
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if strings.HasPrefix(fields[0], "----") {
+			inTable = true
+			continue
+		}
+		if !inTable || len(fields) < 2 {
+			continue
+		}
+
+		pkg := manager.Package{
+			Name:     fields[0],
+			Version:  fields[1],
+			Provider: "scoop",
+			Scope:    installScope(line),
+		}
+		if len(fields) > 2 {
+			pkg.Source = fields[2]
+		}
+		packages = append(packages, pkg)
+	}
+
+	return packages
+}
+
+func (s *ScoopManager) ListOutdated(ctx context.Context) ([]manager.OutdatedPackage, error) {
+	output, err := s.exec.CombinedOutput(ctx, "scoop", "status")
+	if err != nil {
+		return nil, fmt.Errorf("scoop status failed: %v\n%s", err, string(output))
+	}
+
+	return parseScoopStatus(string(output)), nil
+}
+
+// parseScoopStatus parses "scoop status" output. Recent versions print a table
+//
+//	Name Installed Version Latest Version Missing Dependencies Info
+//	---- ----------------- -------------- -------------------- ----
+//	git  2.40.0            2.41.0
+//
+// while older ones print "git: 2.40.0 -> 2.41.0" lines.
+func parseScoopStatus(output string) []manager.OutdatedPackage {
+	packages := make([]manager.OutdatedPackage, 0)
+	inTable := false
+
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		if strings.HasPrefix(fields[0], "----") {
+			inTable = true
+			continue
+		}
+
+		var name, current, latest string
+		switch {
+		case len(fields) == 4 && fields[2] == "->":
+			name, current, latest = strings.TrimSuffix(fields[0], ":"), fields[1], fields[3]
+		case inTable && len(fields) >= 3:
+			name, current, latest = fields[0], fields[1], fields[2]
+		default:
+			continue
+		}
+
+		packages = append(packages, manager.OutdatedPackage{
+			Name:     name,
+			Provider: "scoop",
+			Current:  current,
+			Wanted:   latest,
+			Latest:   latest,
+		})
+	}
+
+	return packages
+}
+
+func (s *ScoopManager) IsInstalled(ctx context.Context, pkg string) bool {
+	// scoop prefix fails for apps that are not installed
+	if err := s.exec.Run(ctx, "scoop", "prefix", pkg); err != nil {
+		return false
+	}
+	return true
+}
+
+func (s *ScoopManager) IsAvailable(ctx context.Context) bool {
+	if err := s.exec.Run(ctx, "scoop", "--version"); err != nil {
 		return false
 	}
 	return true
 }
+
+func (s *ScoopManager) Version(ctx context.Context) (string, error) {
+	// scoop prints a "Current Scoop version:" header followed by e.g.
+	// "v0.4.2 - Released at 2024-05-14" and the versions of its buckets
+	output, err := s.exec.Output(ctx, "scoop", "--version")
+	if err != nil {
+		return "", fmt.Errorf("scoop --version failed: %v", err)
+	}
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		version := strings.TrimPrefix(fields[0], "v")
+		if version != "" && version[0] >= '0' && version[0] <= '9' {
+			return version, nil
+		}
+	}
+	return "", fmt.Errorf("unexpected scoop --version output: %q", string(output))
+}
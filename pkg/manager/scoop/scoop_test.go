@@ -0,0 +1,390 @@
+package scoop
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/RichestHumanAlive/ppm_cli/pkg/executor/executortest"
+	"github.com/RichestHumanAlive/ppm_cli/pkg/manager"
+)
+
+func TestInstall(t *testing.T) {
+	fake := executortest.New().On("scoop install git", executortest.Response{})
+	if err := New(WithExecutor(fake)).Install(context.Background(), manager.PackageSpec{Name: "git"}); err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+
+	fake = executortest.New().On("scoop install nope", executortest.Response{Stdout: "Couldn't find manifest for 'nope'.", ExitCode: 1})
+	if err := New(WithExecutor(fake)).Install(context.Background(), manager.PackageSpec{Name: "nope"}); err == nil {
+		t.Error("Install() error = nil, want error")
+	}
+}
+
+func TestSearch(t *testing.T) {
+	fake := executortest.New()
+
+	pkgs, err := New(WithExecutor(fake), WithRoot("testdata")).Search(context.Background(), "git")
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+
+	want := []struct{ name, bucket string }{
+		{"git", "legacy"},
+		{"git", "main"},
+		{"gitui", "extras"},
+		{"lazygit", "extras"},
+	}
+	if len(pkgs) != len(want) {
+		t.Fatalf("Search() = %+v, want %d packages", pkgs, len(want))
+	}
+	for i, w := range want {
+		if pkgs[i].Name != w.name || pkgs[i].Source != w.bucket {
+			t.Errorf("Search()[%d] = %s (%s), want %s (%s)", i, pkgs[i].Name, pkgs[i].Source, w.name, w.bucket)
+		}
+	}
+
+	git := pkgs[1]
+	if git.Version != "2.45.1" || git.Provider != "scoop" || git.Score != 1 {
+		t.Errorf("Search() = %+v", git)
+	}
+	if git.Description != "Distributed version control system" || git.Homepage != "https://gitforwindows.org" || git.License != "GPL-2.0-only" {
+		t.Errorf("Search() metadata = %+v", git)
+	}
+
+	if calls := fake.Calls(); len(calls) != 0 {
+		t.Errorf("calls = %v, want scoop not to run", calls)
+	}
+}
+
+func TestSearchLicenseObject(t *testing.T) {
+	pkgs, err := New(WithRoot("testdata")).Search(context.Background(), "7zip")
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(pkgs) != 1 || pkgs[0].License != "LGPL-2.1-or-later" || pkgs[0].Version != "23.01" {
+		t.Errorf("Search() = %+v", pkgs)
+	}
+}
+
+func TestSearchNoMatch(t *testing.T) {
+	pkgs, err := New(WithRoot("testdata")).Search(context.Background(), "nothing-like-this")
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(pkgs) != 0 {
+		t.Errorf("Search() = %+v, want no results", pkgs)
+	}
+}
+
+func TestSearchFailure(t *testing.T) {
+	if _, err := New(WithRoot(t.TempDir())).Search(context.Background(), "git"); err == nil {
+		t.Error("Search() error = nil, want error without buckets")
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	tests := []struct {
+		pkg     string
+		cmdline string
+	}{
+		{"git", "scoop update git"},
+		{"", "scoop update *"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cmdline, func(t *testing.T) {
+			fake := executortest.New().On(tt.cmdline, executortest.Response{})
+			if err := New(WithExecutor(fake)).Update(context.Background(), tt.pkg); err != nil {
+				t.Fatalf("Update(%q) error = %v", tt.pkg, err)
+			}
+		})
+	}
+
+	fake := executortest.New().On("scoop update git", executortest.Response{ExitCode: 1})
+	if err := New(WithExecutor(fake)).Update(context.Background(), "git"); err == nil {
+		t.Error("Update() error = nil, want error")
+	}
+}
+
+func TestRemove(t *testing.T) {
+	fake := executortest.New().On("scoop uninstall git", executortest.Response{})
+	if err := New(WithExecutor(fake)).Remove(context.Background(), "git"); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+
+	fake = executortest.New().On("scoop uninstall git", executortest.Response{ExitCode: 1})
+	if err := New(WithExecutor(fake)).Remove(context.Background(), "git"); err == nil {
+		t.Error("Remove() error = nil, want error")
+	}
+}
+
+func TestListInstalledFromExport(t *testing.T) {
+	fake := executortest.New().
+		On("scoop export", executortest.Response{Stdout: `{"buckets":[{"Name":"main"}],"apps":[
+			{"Name":"git","Version":"2.45.1","Source":"main"},
+			{"Name":"vscode","Version":"1.89.1","Source":"extras","Info":"Global install"}]}`})
+
+	pkgs, err := New(WithExecutor(fake)).ListInstalled(context.Background())
+	if err != nil {
+		t.Fatalf("ListInstalled() error = %v", err)
+	}
+	if len(pkgs) != 2 {
+		t.Fatalf("ListInstalled() returned %d packages, want 2", len(pkgs))
+	}
+	if pkgs[1].Name != "vscode" || pkgs[1].Version != "1.89.1" || pkgs[1].Source != "extras" || pkgs[1].Scope != "global" {
+		t.Errorf("ListInstalled()[1] = %+v", pkgs[1])
+	}
+}
+
+func TestListTopLevel(t *testing.T) {
+	fake := executortest.New().
+		On("scoop export", executortest.Response{Stdout: `{"apps":[
+			{"Name":"git","Version":"2.45.1","Source":"main"},
+			{"Name":"lazygit","Version":"0.42.0","Source":"extras"},
+			{"Name":"7zip","Version":"24.07","Source":"main"},
+			{"Name":"mytool","Version":"1.0","Source":"https://example.com/mytool.json"}]}`})
+
+	pkgs, err := New(WithExecutor(fake), WithRoot("testdata")).ListTopLevel(context.Background())
+	if err != nil {
+		t.Fatalf("ListTopLevel() error = %v", err)
+	}
+	var names []string
+	for _, pkg := range pkgs {
+		names = append(names, pkg.Name)
+	}
+	// git is a dependency of lazygit
+	if got := strings.Join(names, " "); got != "lazygit 7zip mytool" {
+		t.Errorf("ListTopLevel() = %s, want lazygit 7zip mytool", got)
+	}
+}
+
+func TestListInstalledFromList(t *testing.T) {
+	fake := executortest.New().
+		On("scoop export", executortest.Response{Stdout: "git 2.45.1 [main]\n"}).
+		On("scoop list", executortest.Response{Stdout: `Installed apps:
+
+Name   Version Source Updated             Info
+----   ------- ------ -------             ----
+7zip   23.01   main   2024-05-01 10:00:00
+git    2.45.1  main   2024-05-20 09:12:44
+`})
+
+	pkgs, err := New(WithExecutor(fake)).ListInstalled(context.Background())
+	if err != nil {
+		t.Fatalf("ListInstalled() error = %v", err)
+	}
+	if len(pkgs) != 2 {
+		t.Fatalf("ListInstalled() returned %d packages, want 2", len(pkgs))
+	}
+	if pkgs[0].Name != "7zip" || pkgs[0].Version != "23.01" || pkgs[0].Source != "main" {
+		t.Errorf("ListInstalled()[0] = %+v", pkgs[0])
+	}
+}
+
+func TestListInstalledFailure(t *testing.T) {
+	fake := executortest.New().
+		On("scoop export", executortest.Response{ExitCode: 1}).
+		On("scoop list", executortest.Response{ExitCode: 1})
+
+	if _, err := New(WithExecutor(fake)).ListInstalled(context.Background()); err == nil {
+		t.Error("ListInstalled() error = nil, want error")
+	}
+}
+
+func TestListOutdated(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+	}{
+		{
+			name: "table",
+			output: `Name Installed Version Latest Version Missing Dependencies Info
+---- ----------------- -------------- -------------------- ----
+git  2.40.0            2.45.1
+`,
+		},
+		{
+			name: "legacy",
+			output: `Updates are available for:
+    git: 2.40.0 -> 2.45.1
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := executortest.New().On("scoop status", executortest.Response{Stdout: tt.output})
+
+			pkgs, err := New(WithExecutor(fake)).ListOutdated(context.Background())
+			if err != nil {
+				t.Fatalf("ListOutdated() error = %v", err)
+			}
+			if len(pkgs) != 1 {
+				t.Fatalf("ListOutdated() returned %d packages, want 1", len(pkgs))
+			}
+			if pkg := pkgs[0]; pkg.Name != "git" || pkg.Current != "2.40.0" || pkg.Latest != "2.45.1" || pkg.Provider != "scoop" {
+				t.Errorf("ListOutdated() = %+v", pkg)
+			}
+		})
+	}
+}
+
+func TestListOutdatedFailure(t *testing.T) {
+	fake := executortest.New().On("scoop status", executortest.Response{ExitCode: 1})
+	if _, err := New(WithExecutor(fake)).ListOutdated(context.Background()); err == nil {
+		t.Error("ListOutdated() error = nil, want error")
+	}
+}
+
+func TestIsInstalled(t *testing.T) {
+	fake := executortest.New().
+		On("scoop prefix git", executortest.Response{Stdout: `C:\Users\me\scoop\apps\git\current`}).
+		On("scoop prefix missing", executortest.Response{ExitCode: 1})
+
+	s := New(WithExecutor(fake))
+	if !s.IsInstalled(context.Background(), "git") {
+		t.Error("IsInstalled(git) = false, want true")
+	}
+	if s.IsInstalled(context.Background(), "missing") {
+		t.Error("IsInstalled(missing) = true, want false")
+	}
+}
+
+func TestIsAvailable(t *testing.T) {
+	fake := executortest.New().On("scoop --version", executortest.Response{})
+	if !New(WithExecutor(fake)).IsAvailable(context.Background()) {
+		t.Error("IsAvailable() = false, want true")
+	}
+
+	if New(WithExecutor(executortest.New())).IsAvailable(context.Background()) {
+		t.Error("IsAvailable() = true, want false")
+	}
+}
+
+func TestFormatSpec(t *testing.T) {
+	tests := []struct {
+		spec    manager.PackageSpec
+		want    string
+		wantErr bool
+	}{
+		{spec: manager.PackageSpec{Name: "git"}, want: "git"},
+		{spec: manager.PackageSpec{Name: "extras/vscode"}, want: "extras/vscode"},
+		{spec: manager.PackageSpec{Name: "git", Constraint: "2.45.1"}, want: "git@2.45.1"},
+		{spec: manager.PackageSpec{Name: "git", Constraint: "==2.45.1"}, want: "git@2.45.1"},
+		{spec: manager.PackageSpec{Name: "git", Constraint: "^2.45"}, wantErr: true},
+		{spec: manager.PackageSpec{Name: "git", Constraint: ">=2"}, wantErr: true},
+		{spec: manager.PackageSpec{Name: "git", Extras: []string{"lfs"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec.String(), func(t *testing.T) {
+			got, err := FormatSpec(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FormatSpec() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("FormatSpec() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVersion(t *testing.T) {
+	fake := executortest.New().On("scoop --version", executortest.Response{Stdout: `Current Scoop version:
+v0.4.2 - Released at 2024-05-14
+
+'main' bucket:
+5e0b2b0a1 (HEAD -> master, origin/master) git: Update to version 2.45.1
+`})
+	version, err := New(WithExecutor(fake)).Version(context.Background())
+	if err != nil || version != "0.4.2" {
+		t.Errorf("Version() = %q, %v, want 0.4.2", version, err)
+	}
+
+	fake = executortest.New().On("scoop --version", executortest.Response{Stdout: "Current Scoop version:\n"})
+	if _, err := New(WithExecutor(fake)).Version(context.Background()); err == nil {
+		t.Error("Version() error = nil, want error")
+	}
+}
+
+func TestSupportsOS(t *testing.T) {
+	s := New()
+	if !manager.SupportsOS(s, "windows") || manager.SupportsOS(s, "linux") {
+		t.Error("SupportsOS() should only allow windows")
+	}
+}
+
+func TestResolve(t *testing.T) {
+	s := New(WithRoot("testdata"))
+
+	tests := []struct {
+		spec    manager.PackageSpec
+		version string
+		hashes  []string
+	}{
+		{
+			spec:    manager.PackageSpec{Name: "git"},
+			version: "2.45.1",
+			hashes:  []string{"sha256:9a5a5e5d0b8a1d9a8f0a4c2ab0f1fbf6e0e8b0e7a6a63d2a4f8b0c9f0e1d2c3b"},
+		},
+		{
+			spec:    manager.PackageSpec{Name: "legacy/git"},
+			version: "2.40.0",
+		},
+		{
+			spec:    manager.PackageSpec{Name: "legacy/git", Constraint: ">=2.40,<3"},
+			version: "2.40.0",
+		},
+		{
+			spec:    manager.PackageSpec{Name: "gitui", Constraint: "0.26.3"},
+			version: "0.26.3",
+			hashes:  []string{"sha256:1b5c8b3e4c1c2a6b5ad0f0b1e6d25a3f0f2bce1a0f1e0c7d1a4b9c6e8d7f6a5b", "sha1:4d1c5e2f3a6b7c8d9e0f1a2b3c4d5e6f7a8b9c0d"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec.String(), func(t *testing.T) {
+			resolved, err := s.Resolve(context.Background(), tt.spec)
+			if err != nil {
+				t.Fatalf("Resolve() error = %v", err)
+			}
+			if resolved.Version != tt.version || strings.Join(resolved.Hashes, " ") != strings.Join(tt.hashes, " ") {
+				t.Errorf("Resolve() = %+v, want %s %v", resolved, tt.version, tt.hashes)
+			}
+		})
+	}
+
+	for _, spec := range []manager.PackageSpec{{Name: "git", Constraint: "2.40.0"}, {Name: "missing"}} {
+		if _, err := s.Resolve(context.Background(), spec); err == nil {
+			t.Errorf("Resolve(%s) error = nil, want error", spec)
+		}
+	}
+}
+
+func TestGetInfo(t *testing.T) {
+	s := New(WithRoot("testdata"))
+
+	info, err := s.GetInfo(context.Background(), "extras/lazygit")
+	if err != nil {
+		t.Fatalf("GetInfo() error = %v", err)
+	}
+	if info.Name != "lazygit" || info.Version != "0.42.0" || info.Source != "extras" || info.License != "MIT" {
+		t.Errorf("GetInfo() = %+v", info.Package)
+	}
+	if len(info.Releases) != 1 || info.Releases[0].Version != "0.42.0" {
+		t.Errorf("GetInfo() releases = %+v", info.Releases)
+	}
+	if len(info.Dependencies) != 1 || info.Dependencies[0] != "git" {
+		t.Errorf("GetInfo() dependencies = %v", info.Dependencies)
+	}
+
+	if _, err := s.GetInfo(context.Background(), "missing"); !isNotFound(err) {
+		t.Errorf("GetInfo(missing) error = %v, want *manager.NotFoundError", err)
+	}
+}
+
+func isNotFound(err error) bool {
+	_, ok := err.(*manager.NotFoundError)
+	return ok
+}
@@ -0,0 +1,152 @@
+package manager
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PackageSpec identifies a package to install independently of the package
+// manager, optionally pinned to a version constraint. It is parsed from forms
+// such as "lodash@4.17.21", "requests>=2.31", "requests[socks]==2.31.0",
+// "@types/node@^20" and "npm:typescript@5".
+type PackageSpec struct {
+	Provider   string   // Package manager hint (npm, pip, scoop), empty if any
+	Name       string   // Package name
+	Extras     []string // Optional extras, e.g. "socks" in requests[socks]
+	Constraint string   // Version constraint, e.g. "4.17.21", "^1.2" or ">=2.31"
+}
+
+// pep440Operators are the comparison operators that start a pip style
+// constraint, longest first so "===" is not read as "=="
+var pep440Operators = []string{"===", "==", "!=", "~=", ">=", "<=", ">", "<"}
+
+// ParseSpec parses a package spec. The provider prefix is not validated here
+// since the set of package managers is only known to the caller.
+func ParseSpec(s string) (PackageSpec, error) {
+	var spec PackageSpec
+
+	rest := strings.TrimSpace(s)
+	if rest == "" {
+		return spec, fmt.Errorf("empty package spec")
+	}
+
+	if i := strings.Index(rest, ":"); i > 0 && isProviderName(rest[:i]) {
+		spec.Provider = rest[:i]
+		rest = rest[i+1:]
+	}
+
+	if at := strings.LastIndex(rest, "@"); at > 0 {
+		// npm style "name@version"; an "@" at the start is an npm scope
+		// like "@types/node" rather than a version separator
+		spec.Constraint = strings.TrimSpace(rest[at+1:])
+		rest = rest[:at]
+		if spec.Constraint == "" {
+			return spec, fmt.Errorf("invalid package spec %q: missing version after '@'", s)
+		}
+	} else if i := strings.IndexAny(rest, "=!~<>"); i >= 0 {
+		// pip style constraint such as ">=2.31" or "==1.0,<2"
+		spec.Constraint = strings.TrimSpace(rest[i:])
+		rest = rest[:i]
+	}
+
+	if i := strings.Index(rest, "["); i >= 0 {
+		if !strings.HasSuffix(rest, "]") {
+			return spec, fmt.Errorf("invalid package spec %q: unterminated extras", s)
+		}
+		for _, extra := range strings.Split(rest[i+1:len(rest)-1], ",") {
+			if extra = strings.TrimSpace(extra); extra != "" {
+				spec.Extras = append(spec.Extras, extra)
+			}
+		}
+		rest = rest[:i]
+	}
+
+	spec.Name = strings.TrimSpace(rest)
+	if spec.Name == "" || strings.ContainsAny(spec.Name, " \t^*|,;") {
+		return spec, fmt.Errorf("invalid package spec %q: missing or invalid package name", s)
+	}
+
+	return spec, nil
+}
+
+// isProviderName reports whether s looks like a provider prefix such as "npm"
+func isProviderName(s string) bool {
+	for _, r := range s {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// String formats the spec back into the provider-neutral form
+func (s PackageSpec) String() string {
+	var sb strings.Builder
+	if s.Provider != "" {
+		sb.WriteString(s.Provider + ":")
+	}
+	sb.WriteString(s.Name)
+	if len(s.Extras) > 0 {
+		sb.WriteString("[" + strings.Join(s.Extras, ",") + "]")
+	}
+	if s.Constraint != "" {
+		if strings.ContainsAny(s.Constraint[:1], "=!~<>") {
+			sb.WriteString(s.Constraint)
+		} else {
+			sb.WriteString("@" + s.Constraint)
+		}
+	}
+	return sb.String()
+}
+
+// ExactVersion returns the pinned version when the constraint names exactly
+// one version, e.g. "4.17.21", "=4.17.21" or "==4.17.21". Partial versions
+// such as "5" or "23.1" name every release they start, as in Satisfies, and
+// are not exact.
+func (s PackageSpec) ExactVersion() (string, bool) {
+	v, ok := s.SingleVersion()
+	if !ok || IsPartialVersion(v) {
+		return "", false
+	}
+	return v, true
+}
+
+// SingleVersion returns the version the constraint names without any
+// operator other than "=" or "==", whether exact or partial: "4.17.21" and
+// "5" but not "^5" or "1.x"
+func (s PackageSpec) SingleVersion() (string, bool) {
+	v := s.Constraint
+	for _, prefix := range []string{"===", "==", "="} {
+		if strings.HasPrefix(v, prefix) {
+			v = strings.TrimSpace(v[len(prefix):])
+			break
+		}
+	}
+
+	if v == "" || strings.ContainsAny(v, "=!~<>^|, ") || HasWildcard(v) {
+		return "", false
+	}
+	return v, true
+}
+
+// HasWildcard reports whether a version uses "x" or "*" components, as in
+// "1.x" or "2.*"
+func HasWildcard(version string) bool {
+	for _, part := range strings.Split(version, ".") {
+		if part == "x" || part == "X" || part == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// ConstraintOperator returns the leading comparison operator of a pip style
+// constraint, or "" when the constraint does not start with one
+func (s PackageSpec) ConstraintOperator() string {
+	for _, op := range pep440Operators {
+		if strings.HasPrefix(s.Constraint, op) {
+			return op
+		}
+	}
+	return ""
+}
@@ -0,0 +1,86 @@
+package manager
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSpec(t *testing.T) {
+	tests := []struct {
+		in   string
+		want PackageSpec
+	}{
+		{"lodash", PackageSpec{Name: "lodash"}},
+		{"lodash@4.17.21", PackageSpec{Name: "lodash", Constraint: "4.17.21"}},
+		{"lodash@^4.17", PackageSpec{Name: "lodash", Constraint: "^4.17"}},
+		{"lodash@>=4 <5", PackageSpec{Name: "lodash", Constraint: ">=4 <5"}},
+		{"@types/node", PackageSpec{Name: "@types/node"}},
+		{"@types/node@20", PackageSpec{Name: "@types/node", Constraint: "20"}},
+		{"npm:typescript@5", PackageSpec{Provider: "npm", Name: "typescript", Constraint: "5"}},
+		{"requests>=2.31", PackageSpec{Name: "requests", Constraint: ">=2.31"}},
+		{"requests==2.31.0", PackageSpec{Name: "requests", Constraint: "==2.31.0"}},
+		{"requests>=2.31,<3", PackageSpec{Name: "requests", Constraint: ">=2.31,<3"}},
+		{"requests[socks,security]~=2.31", PackageSpec{Name: "requests", Extras: []string{"socks", "security"}, Constraint: "~=2.31"}},
+		{"pip:black", PackageSpec{Provider: "pip", Name: "black"}},
+		{"scoop:extras/vscode", PackageSpec{Provider: "scoop", Name: "extras/vscode"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseSpec(tt.in)
+			if err != nil {
+				t.Fatalf("ParseSpec(%q) error = %v", tt.in, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseSpec(%q) = %+v, want %+v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSpecErrors(t *testing.T) {
+	for _, in := range []string{"", "   ", "lodash@", "npm:", ">=2.31", "requests[socks", "requests^2"} {
+		if _, err := ParseSpec(in); err == nil {
+			t.Errorf("ParseSpec(%q) error = nil, want error", in)
+		}
+	}
+}
+
+func TestSpecString(t *testing.T) {
+	for _, in := range []string{"lodash", "npm:typescript@5", "requests[socks]>=2.31", "@types/node@^20"} {
+		spec, err := ParseSpec(in)
+		if err != nil {
+			t.Fatalf("ParseSpec(%q) error = %v", in, err)
+		}
+		if got := spec.String(); got != in {
+			t.Errorf("String() = %q, want %q", got, in)
+		}
+	}
+}
+
+func TestExactVersion(t *testing.T) {
+	tests := []struct {
+		constraint string
+		want       string
+		ok         bool
+	}{
+		{"4.17.21", "4.17.21", true},
+		{"=4.17.21", "4.17.21", true},
+		{"==2.31.0", "2.31.0", true},
+		{"", "", false},
+		{"^4.17", "", false},
+		{">=2.31", "", false},
+		{"1.x", "", false},
+		{"2.*", "", false},
+		{"5", "", false},
+		{"==23.1", "", false},
+		{"2.0b1", "2.0b1", true},
+	}
+
+	for _, tt := range tests {
+		got, ok := PackageSpec{Name: "pkg", Constraint: tt.constraint}.ExactVersion()
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ExactVersion(%q) = %q, %v, want %q, %v", tt.constraint, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,306 @@
+package manager
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// CompareVersions compares two dotted version strings such as "1.10.0" and
+// "1.9", returning -1, 0 or 1. Numeric components compare as numbers, a
+// leading "v" and build metadata ("+build.5") are ignored, a pre-release
+// suffix ("1.0.0-rc1", "2.0b1") sorts before the release it precedes and a
+// post-release ("1.0.post1") after it. It is not a full semver or PEP 440
+// implementation, but orders the versions npm, pip and scoop report.
+func CompareVersions(a, b string) int {
+	a, b = trimVersion(a), trimVersion(b)
+
+	ap, bp := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(ap) || i < len(bp); i++ {
+		var as, bs string
+		if i < len(ap) {
+			as = ap[i]
+		}
+		if i < len(bp) {
+			bs = bp[i]
+		}
+		if c := compareComponent(as, bs); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// compareComponent compares one dot separated component, e.g. "10" and "9"
+// or "0-rc1" and "0". Missing components count as zero.
+func compareComponent(a, b string) int {
+	an, arest := splitNumber(a)
+	bn, brest := splitNumber(b)
+	if an != bn {
+		if an < bn {
+			return -1
+		}
+		return 1
+	}
+
+	// Equal numbers: a release sorts after any pre-release of it and before
+	// any post-release
+	if ar, br := suffixRank(arest), suffixRank(brest); ar != br {
+		if ar < br {
+			return -1
+		}
+		return 1
+	}
+	if ar, br := strings.TrimPrefix(arest, "post"), strings.TrimPrefix(brest, "post"); ar != arest && br != brest {
+		// Post-releases are numbered: post10 follows post9
+		return compareComponent(ar, br)
+	}
+	switch {
+	case arest == brest:
+		return 0
+	case arest < brest:
+		return -1
+	default:
+		return 1
+	}
+}
+
+// suffixRank orders the suffixes of equal version numbers: pre-releases
+// before the release, which is before post-releases
+func suffixRank(suffix string) int {
+	switch {
+	case suffix == "":
+		return 0
+	case strings.HasPrefix(suffix, "post"):
+		return 1
+	default:
+		return -1
+	}
+}
+
+// trimVersion strips the surrounding space, leading "v" and build metadata of
+// a version, which do not take part in comparisons
+func trimVersion(version string) string {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if i := strings.IndexByte(version, '+'); i >= 0 {
+		version = version[:i]
+	}
+	return version
+}
+
+// splitNumber splits a component into its leading number and the rest
+func splitNumber(s string) (int, string) {
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	n, _ := strconv.Atoi(s[:i])
+	return n, strings.TrimLeft(s[i:], "-+_.")
+}
+
+// Satisfies reports whether version meets a constraint in either npm or pip
+// syntax: exact and partial versions ("5", "5.1.x", "==2.*"), comparisons
+// (">=2.31,<3" or ">=2.31 <3"), caret and tilde ranges ("^5.4", "~1.2",
+// "~=2.31"), exclusions ("!=1.0") and alternatives joined with "||". An
+// empty constraint or "*" accepts any version.
+func Satisfies(version, constraint string) (bool, error) {
+	constraint = strings.TrimSpace(constraint)
+	if constraint == "" {
+		return true, nil
+	}
+
+	for _, alternative := range strings.Split(constraint, "||") {
+		ok, err := satisfiesAll(version, alternative)
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// satisfiesAll reports whether version meets every comparator of a range
+func satisfiesAll(version, constraint string) (bool, error) {
+	// npm hyphen ranges such as "1.2 - 2.3" include both ends
+	if lo, hi, ok := strings.Cut(constraint, " - "); ok {
+		constraint = ">=" + strings.TrimSpace(lo) + " <=" + strings.TrimSpace(hi)
+	}
+
+	comparators := strings.FieldsFunc(constraint, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+	if len(comparators) == 0 {
+		return false, fmt.Errorf("empty version range in %q", constraint)
+	}
+
+	for i := 0; i < len(comparators); i++ {
+		c := comparators[i]
+		// Allow a space between an operator and its version, as in ">= 2"
+		if strings.Trim(c, "=!~<>^") == "" && i+1 < len(comparators) {
+			c += comparators[i+1]
+			i++
+		}
+		ok, err := satisfiesOne(version, c)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// satisfiesOne reports whether version meets a single comparator
+func satisfiesOne(version, comparator string) (bool, error) {
+	op := ""
+	for _, candidate := range []string{"===", "==", "!=", "~=", ">=", "<=", ">", "<", "=", "^", "~"} {
+		if strings.HasPrefix(comparator, candidate) {
+			op = candidate
+			break
+		}
+	}
+	target := strings.TrimPrefix(strings.TrimSpace(comparator[len(op):]), "v")
+	if target == "" {
+		return false, fmt.Errorf("missing version in %q", comparator)
+	}
+	if target == "*" || target == "x" || target == "X" {
+		return op != "!=", nil
+	}
+	if target[0] < '0' || target[0] > '9' {
+		return false, fmt.Errorf("invalid version in %q", comparator)
+	}
+
+	parts, partial := versionParts(target)
+	cmp := CompareVersions(version, target)
+
+	switch op {
+	case "", "=", "==", "===":
+		if partial {
+			return hasPrefix(version, parts), nil
+		}
+		return cmp == 0, nil
+	case "!=":
+		if partial {
+			return !hasPrefix(version, parts), nil
+		}
+		return cmp != 0, nil
+	case ">=":
+		return cmp >= 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	case "<":
+		return cmp < 0, nil
+	case "^":
+		// Changes that do not modify the left-most non-zero component
+		i := 0
+		for i < len(parts)-1 && parts[i] == 0 {
+			i++
+		}
+		return cmp >= 0 && CompareVersions(version, bump(parts, i)) < 0, nil
+	case "~":
+		// Patch changes when a minor version is given, minor changes otherwise
+		i := 1
+		if len(parts) == 1 {
+			i = 0
+		}
+		return cmp >= 0 && CompareVersions(version, bump(parts, i)) < 0, nil
+	case "~=":
+		// PEP 440 compatible release: drop the last component and bump
+		if len(parts) < 2 {
+			return false, fmt.Errorf("%q needs at least two version components", comparator)
+		}
+		return cmp >= 0 && CompareVersions(version, bump(parts, len(parts)-2)) < 0, nil
+	}
+	return false, fmt.Errorf("unsupported version comparator %q", comparator)
+}
+
+// versionParts returns the leading numeric components of a version and
+// whether it is partial (see IsPartialVersion)
+func versionParts(version string) ([]int, bool) {
+	fields := strings.Split(version, ".")
+	parts := make([]int, 0, len(fields))
+	for _, f := range fields {
+		if f == "x" || f == "X" || f == "*" {
+			return parts, true
+		}
+		n, _ := splitNumber(f)
+		parts = append(parts, n)
+	}
+	return parts, IsPartialVersion(version)
+}
+
+// IsPartialVersion reports whether a version leaves components open: it is
+// numeric and shorter than major.minor.patch, as "5" or "23.1", or ends in a
+// wildcard, as "1.x" or "2.*". Versions with a suffix, such as "2.0b1", are
+// complete.
+func IsPartialVersion(version string) bool {
+	if HasWildcard(version) {
+		return true
+	}
+	fields := strings.Split(trimVersion(version), ".")
+	for _, f := range fields {
+		if _, rest := splitNumber(f); rest != "" || f == "" {
+			return false
+		}
+	}
+	return len(fields) < 3
+}
+
+// hasPrefix reports whether the numeric components of version start with parts
+func hasPrefix(version string, parts []int) bool {
+	vparts, _ := versionParts(strings.TrimPrefix(version, "v"))
+	if len(vparts) < len(parts) {
+		vparts = append(vparts, make([]int, len(parts)-len(vparts))...)
+	}
+	for i, p := range parts {
+		if vparts[i] != p {
+			return false
+		}
+	}
+	return true
+}
+
+// bump increments component i of parts and drops the ones after it, e.g.
+// bump([1 2 3], 0) is "2"; the result is the exclusive upper bound of a range
+func bump(parts []int, i int) string {
+	out := make([]string, 0, i+1)
+	for j := 0; j < i; j++ {
+		out = append(out, strconv.Itoa(parts[j]))
+	}
+	return strings.Join(append(out, strconv.Itoa(parts[i]+1)), ".")
+}
+
+// MaxSatisfying returns the highest of versions that satisfies constraint.
+// Pre-releases are only picked when the constraint mentions one, as both npm
+// and pip do.
+func MaxSatisfying(versions []string, constraint string) (string, bool, error) {
+	allowPre := IsPrerelease(strings.TrimLeft(constraint, "=!~<>^v "))
+	best := ""
+	for _, v := range versions {
+		if IsPrerelease(v) && !allowPre {
+			continue
+		}
+		ok, err := Satisfies(v, constraint)
+		if err != nil {
+			return "", false, err
+		}
+		if ok && (best == "" || CompareVersions(v, best) > 0) {
+			best = v
+		}
+	}
+	return best, best != "", nil
+}
+
+// IsPrerelease reports whether a version carries a pre-release suffix such as
+// "1.0.0-rc.1" or "2.0b1". Post-releases ("1.0.post1") and build metadata
+// ("1.0.0+build.5") do not make a pre-release.
+func IsPrerelease(version string) bool {
+	for _, part := range strings.Split(trimVersion(version), ".") {
+		if _, rest := splitNumber(part); rest != "" && !strings.HasPrefix(rest, "post") {
+			return true
+		}
+	}
+	return false
+}
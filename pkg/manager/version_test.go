@@ -0,0 +1,135 @@
+package manager
+
+import "testing"
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.0.0", "1.0.0", 0},
+		{"v1.2.3", "1.2.3", 0},
+		{"1.10.0", "1.9.0", 1},
+		{"1.9", "1.10", -1},
+		{"2.0", "2.0.0", 0},
+		{"2.0.1", "2.0", 1},
+		{"1.0.0-rc1", "1.0.0", -1},
+		{"2.0b1", "2.0", -1},
+		{"1.0.0-alpha", "1.0.0-beta", -1},
+		{"23.01", "22.01", 1},
+		{"1.0.post1", "1.0", 1},
+		{"1.0", "1.0.post1", -1},
+		{"1.0.post2", "1.0.post1", 1},
+		{"1.0.post10", "1.0.post9", 1},
+		{"1.0.post1", "1.0.1", -1},
+		{"1.0rc1", "1.0.post1", -1},
+		{"1.0.0+build.5", "1.0.0", 0},
+		{"1.0.0-rc1+build.5", "1.0.0", -1},
+	}
+
+	for _, tt := range tests {
+		if got := CompareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("CompareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestSatisfies(t *testing.T) {
+	tests := []struct {
+		version, constraint string
+		want                bool
+	}{
+		{"5.4.5", "", true},
+		{"5.4.5", "*", true},
+		{"5.4.5", "5", true},
+		{"6.0.0", "5", false},
+		{"5.4.5", "5.4.x", true},
+		{"5.5.0", "5.4.x", false},
+		{"5.4.5", "5.4.5", true},
+		{"5.4.6", "5.4.5", false},
+		{"2.31.0", "==2.31.0", true},
+		{"2.31.0", "==2.*", true},
+		{"3.0.0", "==2.*", false},
+		{"2.31.0", ">=2.31,<3", true},
+		{"3.0.0", ">=2.31,<3", false},
+		{"2.30.0", ">= 2.31 < 3", false},
+		{"1.0.0", "!=1.0.0", false},
+		{"5.4.5", "^5.1", true},
+		{"6.0.0", "^5.1", false},
+		{"0.2.5", "^0.2.3", true},
+		{"0.3.0", "^0.2.3", false},
+		{"1.2.9", "~1.2.3", true},
+		{"1.3.0", "~1.2.3", false},
+		{"1.9.0", "~1", true},
+		{"2.31.5", "~=2.31", true},
+		{"3.0", "~=2.31", false},
+		{"2.32.0", "~=2.31.0", false},
+		{"1.5.0", "1.2 - 2.3", true},
+		{"2.4.0", "1.2 - 2.3", false},
+		{"3.1.0", "^1 || ^3", true},
+		{"2.1.0", "^1 || ^3", false},
+	}
+
+	for _, tt := range tests {
+		got, err := Satisfies(tt.version, tt.constraint)
+		if err != nil {
+			t.Errorf("Satisfies(%q, %q) error = %v", tt.version, tt.constraint, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Satisfies(%q, %q) = %v, want %v", tt.version, tt.constraint, got, tt.want)
+		}
+	}
+
+	for _, constraint := range []string{"~=2", ">=", "@1"} {
+		if _, err := Satisfies("1.0.0", constraint); err == nil {
+			t.Errorf("Satisfies(1.0.0, %q) error = nil, want error", constraint)
+		}
+	}
+}
+
+func TestIsPrerelease(t *testing.T) {
+	tests := map[string]bool{
+		"1.0.0":              false,
+		"1.0.0-rc.1":         true,
+		"2.0b1":              true,
+		"1.0.post1":          false,
+		"1.0.0+build.5":      false,
+		"1.0.0+20240101":     false,
+		"1.0.0-beta+exp.sha": true,
+		"v2.1.0":             false,
+	}
+	for version, want := range tests {
+		if got := IsPrerelease(version); got != want {
+			t.Errorf("IsPrerelease(%q) = %v, want %v", version, got, want)
+		}
+	}
+}
+
+func TestMaxSatisfying(t *testing.T) {
+	versions := []string{"4.9.5", "5.0.4", "5.4.5", "5.5.0-beta", "6.0.0-dev.1", "2.31.0", "2.32.3"}
+
+	tests := []struct {
+		constraint string
+		want       string
+	}{
+		{"", "5.4.5"},
+		{"^5", "5.4.5"},
+		{"~5.0", "5.0.4"},
+		{">=2.31,<3", "2.32.3"},
+		{"5.5.0-beta", "5.5.0-beta"},
+		{">=6.0.0-dev", "6.0.0-dev.1"},
+		{"^7", ""},
+	}
+
+	for _, tt := range tests {
+		got, ok, err := MaxSatisfying(versions, tt.constraint)
+		if err != nil {
+			t.Errorf("MaxSatisfying(%q) error = %v", tt.constraint, err)
+			continue
+		}
+		if got != tt.want || ok != (tt.want != "") {
+			t.Errorf("MaxSatisfying(%q) = %q, %v, want %q", tt.constraint, got, ok, tt.want)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+// Package manifest reads ppm.yaml, the project manifest that declares the
+// packages a project needs from each package manager, e.g.
+//
+//	npm:
+//	  - typescript@5
+//	pip:
+//	  - black
+//	  - requests>=2.31
+package manifest
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/RichestHumanAlive/ppm_cli/pkg/manager"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultPath is the file name used when none is given
+const DefaultPath = "ppm.yaml"
+
+// File is a parsed manifest
+type File struct {
+	Packages map[string][]manager.PackageSpec // Declared packages by package manager
+}
+
+// Providers returns the package manager names in the manifest, sorted
+func (f *File) Providers() []string {
+	names := make([]string, 0, len(f.Packages))
+	for name := range f.Packages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Count returns the number of declared packages across all providers
+func (f *File) Count() int {
+	n := 0
+	for _, specs := range f.Packages {
+		n += len(specs)
+	}
+	return n
+}
+
+// Decode parses a manifest. Every entry is a package spec as accepted by
+// "ppm install"; its provider is the section it is listed in.
+func Decode(data []byte) (*File, error) {
+	var raw map[string][]string
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %v", err)
+	}
+
+	f := &File{Packages: make(map[string][]manager.PackageSpec, len(raw))}
+	for provider, entries := range raw {
+		specs := make([]manager.PackageSpec, 0, len(entries))
+		for _, entry := range entries {
+			spec, err := manager.ParseSpec(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid manifest: %s: %v", provider, err)
+			}
+			if spec.Provider != "" && spec.Provider != provider {
+				return nil, fmt.Errorf("invalid manifest: %q is listed under %s", entry, provider)
+			}
+			for _, other := range specs {
+				if manager.SameName(provider, other.Name, spec.Name) {
+					return nil, fmt.Errorf("invalid manifest: %s declares %s more than once", provider, spec.Name)
+				}
+			}
+			spec.Provider = provider
+			specs = append(specs, spec)
+		}
+		f.Packages[provider] = specs
+	}
+
+	return f, nil
+}
+
+// Load reads and parses the manifest at path
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := Decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return f, nil
+}
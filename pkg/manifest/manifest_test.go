@@ -0,0 +1,112 @@
+package manifest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/RichestHumanAlive/ppm_cli/pkg/manager"
+)
+
+const sample = `# Tools this project needs
+npm:
+  - typescript@5
+  - npm:eslint
+pip:
+  - black
+  - requests[socks]>=2.31,<3
+`
+
+func TestDecode(t *testing.T) {
+	f, err := Decode([]byte(sample))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	if f.Count() != 4 || strings.Join(f.Providers(), ",") != "npm,pip" {
+		t.Fatalf("Decode() = %+v", f)
+	}
+	if spec := f.Packages["npm"][0]; spec.Provider != "npm" || spec.Name != "typescript" || spec.Constraint != "5" {
+		t.Errorf("npm[0] = %+v", spec)
+	}
+	if spec := f.Packages["npm"][1]; spec.Provider != "npm" || spec.Name != "eslint" {
+		t.Errorf("npm[1] = %+v", spec)
+	}
+	if spec := f.Packages["pip"][1]; spec.Name != "requests" || spec.Constraint != ">=2.31,<3" || len(spec.Extras) != 1 {
+		t.Errorf("pip[1] = %+v", spec)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := map[string]string{
+		"not yaml":       "npm: [",
+		"not a list":     "npm: typescript",
+		"invalid spec":   "npm:\n  - typescript@",
+		"wrong provider": "npm:\n  - pip:black",
+		"duplicate":      "pip:\n  - typing_extensions\n  - typing-extensions>=4",
+	}
+
+	for name, input := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Decode([]byte(input)); err == nil {
+				t.Error("Decode() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestPlan(t *testing.T) {
+	declared := []manager.PackageSpec{
+		{Name: "typescript", Constraint: "5"},
+		{Name: "eslint", Constraint: "^9"},
+		{Name: "prettier"},
+		{Name: "pnpm", Constraint: "next"},
+	}
+	installed := []manager.Package{
+		{Name: "typescript", Version: "5.4.5"},
+		{Name: "eslint", Version: "8.57.0"},
+		{Name: "pnpm", Version: "9.1.0"},
+		{Name: "npm", Version: "10.5.2"},
+	}
+
+	steps := Plan("npm", declared, installed, nil)
+
+	wantActions := []Action{Keep, Upgrade, Install, Keep}
+	if len(steps) != len(wantActions) {
+		t.Fatalf("Plan() = %+v", steps)
+	}
+	for i, action := range wantActions {
+		if steps[i].Action != action {
+			t.Errorf("Plan()[%d] (%s) action = %s, want %s", i, steps[i].Spec.Name, steps[i].Action, action)
+		}
+	}
+	if s := steps[1]; s.Installed != "8.57.0" || s.Spec.Constraint != "^9" || s.Spec.Provider != "npm" || !s.Pending() {
+		t.Errorf("Plan()[1] = %+v", s)
+	}
+	if steps[3].Reason == "" || steps[3].Pending() {
+		t.Errorf("Plan()[3] = %+v", steps[3])
+	}
+
+	// Pruning removes undeclared packages
+	removable := []manager.Package{{Name: "typescript", Version: "5.4.5"}, {Name: "yarn", Version: "1.22.22"}}
+	steps = Plan("npm", declared, installed, removable)
+	if last := steps[len(steps)-1]; len(steps) != 5 || last.Action != Remove || last.Spec.Name != "yarn" || last.Installed != "1.22.22" {
+		t.Errorf("Plan() with pruning = %+v", steps)
+	}
+
+	// pip names match after PEP 503 normalization, npm names only as written
+	steps = Plan("pip", []manager.PackageSpec{{Name: "typing_extensions"}}, nil, []manager.Package{{Name: "Typing-Extensions"}})
+	if len(steps) != 1 || steps[0].Action != Install {
+		t.Errorf("Plan(pip) with pruning = %+v, want typing_extensions kept", steps)
+	}
+	steps = Plan("npm", []manager.PackageSpec{{Name: "lodash.get"}}, nil, []manager.Package{{Name: "lodash-get"}})
+	if len(steps) != 2 || steps[1].Action != Remove {
+		t.Errorf("Plan(npm) with pruning = %+v, want lodash-get removed", steps)
+	}
+}
+
+func TestBlocked(t *testing.T) {
+	steps := Blocked("scoop", []manager.PackageSpec{{Name: "git"}}, Unsupported, "scoop does not run on linux")
+	if len(steps) != 1 || steps[0].Action != Unsupported || steps[0].Pending() || steps[0].Spec.Provider != "scoop" {
+		t.Errorf("Blocked() = %+v", steps)
+	}
+}
@@ -0,0 +1,118 @@
+package manifest
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/RichestHumanAlive/ppm_cli/pkg/manager"
+)
+
+// Action is what syncing does with one package
+type Action string
+
+const (
+	Install     Action = "install"     // Declared but not installed
+	Upgrade     Action = "upgrade"     // Installed at a version outside the declared range
+	Remove      Action = "remove"      // Installed but not declared, when pruning
+	Keep        Action = "ok"          // Installed at a version inside the declared range
+	Unsupported Action = "unsupported" // The package manager does not run on this OS
+	Unavailable Action = "unavailable" // The package manager is not installed on this host
+)
+
+// Step is the planned action for one package
+type Step struct {
+	Spec      manager.PackageSpec // Declared spec, or just the name of a package to remove
+	Installed string              // Installed version, empty if not installed
+	Action    Action
+	Reason    string // Why the step changes or skips the package
+}
+
+// Plan compares the packages declared for one package manager with the ones
+// it has installed. Packages in removable that are not declared are removed;
+// pass nil to leave undeclared packages alone.
+// Names are compared the way the package manager does, see manager.SameName.
+func Plan(provider string, declared []manager.PackageSpec, installed, removable []manager.Package) []Step {
+	steps := make([]Step, 0, len(declared))
+	for _, spec := range declared {
+		spec.Provider = provider
+		step := Step{Spec: spec}
+
+		var current *manager.Package
+		for i := range installed {
+			if manager.SameName(provider, installed[i].Name, spec.Name) {
+				current = &installed[i]
+				break
+			}
+		}
+		if current == nil {
+			step.Action = Install
+			steps = append(steps, step)
+			continue
+		}
+
+		step.Installed = current.Version
+		ok, err := manager.Satisfies(current.Version, spec.Constraint)
+		switch {
+		case err != nil:
+			// e.g. an npm dist-tag, which only the registry can evaluate
+			step.Action = Keep
+			step.Reason = fmt.Sprintf("installed, cannot check against %q", spec.Constraint)
+		case ok:
+			step.Action = Keep
+		default:
+			step.Action = Upgrade
+			step.Reason = fmt.Sprintf("%s is outside %s", current.Version, spec.Constraint)
+		}
+		steps = append(steps, step)
+	}
+
+	for _, pkg := range removable {
+		if isDeclared(provider, declared, pkg.Name) {
+			continue
+		}
+		steps = append(steps, Step{
+			Spec:      manager.PackageSpec{Provider: provider, Name: pkg.Name},
+			Installed: pkg.Version,
+			Action:    Remove,
+			Reason:    "not declared",
+		})
+	}
+	return steps
+}
+
+// isDeclared reports whether a package of the given name is declared
+func isDeclared(provider string, declared []manager.PackageSpec, name string) bool {
+	for _, spec := range declared {
+		if manager.SameName(provider, spec.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Blocked returns steps for packages whose package manager cannot be used
+// on this host, with the given action and reason
+func Blocked(provider string, declared []manager.PackageSpec, action Action, reason string) []Step {
+	steps := make([]Step, 0, len(declared))
+	for _, spec := range declared {
+		spec.Provider = provider
+		steps = append(steps, Step{Spec: spec, Action: action, Reason: reason})
+	}
+	return steps
+}
+
+// SortSteps orders steps by provider and package name
+func SortSteps(steps []Step) {
+	sort.SliceStable(steps, func(i, j int) bool {
+		if steps[i].Spec.Provider != steps[j].Spec.Provider {
+			return steps[i].Spec.Provider < steps[j].Spec.Provider
+		}
+		return strings.ToLower(steps[i].Spec.Name) < strings.ToLower(steps[j].Spec.Name)
+	})
+}
+
+// Pending reports whether the step changes the host when applied
+func (s Step) Pending() bool {
+	return s.Action == Install || s.Action == Upgrade || s.Action == Remove
+}
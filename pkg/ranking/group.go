@@ -0,0 +1,191 @@
+package ranking
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/RichestHumanAlive/ppm_cli/pkg/manager"
+)
+
+// Group is one project as published by one or more package managers
+type Group struct {
+	Packages []manager.Package // The preferred package first
+}
+
+// Preferred returns the package to use for the project
+func (g Group) Preferred() manager.Package {
+	return g.Packages[0]
+}
+
+// Alternatives returns the packages of the project other package managers
+// publish
+func (g Group) Alternatives() []manager.Package {
+	return g.Packages[1:]
+}
+
+// codeHosts are the hosts whose URLs name a project by owner and repository
+var codeHosts = map[string]bool{
+	"github.com":    true,
+	"gitlab.com":    true,
+	"bitbucket.org": true,
+	"codeberg.org":  true,
+	"sr.ht":         true,
+	"git.sr.ht":     true,
+}
+
+// ProjectKey reduces a repository or homepage URL to a form that compares
+// equal for URLs of the same project, e.g. "git+https://github.com/psf/black.git"
+// and "https://github.com/psf/black#readme" both become "github.com/psf/black".
+// It returns "" for URLs that do not point at one project.
+func ProjectKey(rawURL string) string {
+	u := strings.ToLower(strings.TrimSpace(rawURL))
+	u = strings.TrimPrefix(u, "git+")
+	if i := strings.Index(u, "://"); i >= 0 {
+		u = u[i+3:]
+	} else if strings.HasPrefix(u, "git@") {
+		// scp-like "git@github.com:owner/repo.git"
+		u = strings.Replace(u, ":", "/", 1)
+	}
+	if i := strings.IndexAny(u, "?#"); i >= 0 {
+		u = u[:i]
+	}
+	if i := strings.Index(u, "@"); i >= 0 && i < strings.Index(u+"/", "/") {
+		u = u[i+1:]
+	}
+	u = strings.TrimPrefix(u, "www.")
+	u = strings.TrimSuffix(strings.TrimRight(u, "/"), ".git")
+
+	parts := strings.Split(u, "/")
+	host := parts[0]
+	if host == "" || !strings.Contains(host, ".") {
+		return ""
+	}
+	if codeHosts[host] {
+		// Anything below the repository, such as "tree/main/docs", still
+		// belongs to it
+		if len(parts) < 3 || parts[1] == "" || parts[2] == "" {
+			return ""
+		}
+		return strings.Join(parts[:3], "/")
+	}
+	return u
+}
+
+// GroupProjects gathers search results, best first, into one group per
+// project: packages of different package managers that share a repository or
+// homepage. A group holds at most one package per package manager, and a URL
+// several packages of one package manager share, such as a monorepo, groups
+// nothing. Groups are ordered by their best package. Within a group the
+// package managers listed in priority come first, in that order, and the
+// others follow by rank.
+func GroupProjects(pkgs []manager.Package, priority []string) []Group {
+	// Projects keyed by URL, dropping the ones a package manager publishes
+	// more than one package of
+	byKey := make(map[string][]int)
+	for i, pkg := range pkgs {
+		seen := make(map[string]bool)
+		for _, key := range []string{ProjectKey(pkg.Repository), ProjectKey(pkg.Homepage)} {
+			if key != "" && !seen[key] {
+				seen[key] = true
+				byKey[key] = append(byKey[key], i)
+			}
+		}
+	}
+
+	// Union-find over package indexes, each set remembering its package
+	// managers so no set gets two packages of the same one
+	parent := make([]int, len(pkgs))
+	providers := make([]map[string]bool, len(pkgs))
+	for i, pkg := range pkgs {
+		parent[i] = i
+		providers[i] = map[string]bool{pkg.Provider: true}
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	keys := make([]string, 0, len(byKey))
+	for key := range byKey {
+		keys = append(keys, key)
+	}
+	// Merge in a fixed order so the groups do not depend on map iteration
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := byKey[keys[i]], byKey[keys[j]]
+		if a[0] != b[0] {
+			return a[0] < b[0]
+		}
+		return keys[i] < keys[j]
+	})
+
+	for _, key := range keys {
+		members := byKey[key]
+		count := make(map[string]int)
+		for _, i := range members {
+			count[pkgs[i].Provider]++
+		}
+		if len(count) < 2 || len(count) < len(members) {
+			continue
+		}
+
+		for _, i := range members[1:] {
+			a, b := find(members[0]), find(i)
+			if a == b || sharesProvider(providers[a], providers[b]) {
+				continue
+			}
+			if b < a {
+				a, b = b, a
+			}
+			parent[b] = a
+			for p := range providers[b] {
+				providers[a][p] = true
+			}
+		}
+	}
+
+	// Roots are the best ranked package of their set, so collecting in rank
+	// order orders the groups by their best package
+	index := make(map[int]int)
+	groups := make([]Group, 0, len(pkgs))
+	for i, pkg := range pkgs {
+		root := find(i)
+		g, ok := index[root]
+		if !ok {
+			g = len(groups)
+			index[root] = g
+			groups = append(groups, Group{})
+		}
+		groups[g].Packages = append(groups[g].Packages, pkg)
+	}
+
+	rank := make(map[string]int, len(priority))
+	for i, provider := range priority {
+		if _, ok := rank[provider]; !ok {
+			rank[provider] = i
+		}
+	}
+	preference := func(provider string) int {
+		if r, ok := rank[provider]; ok {
+			return r
+		}
+		return len(priority)
+	}
+	for _, g := range groups {
+		sort.SliceStable(g.Packages, func(i, j int) bool {
+			return preference(g.Packages[i].Provider) < preference(g.Packages[j].Provider)
+		})
+	}
+	return groups
+}
+
+func sharesProvider(a, b map[string]bool) bool {
+	for p := range a {
+		if b[p] {
+			return true
+		}
+	}
+	return false
+}
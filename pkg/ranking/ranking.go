@@ -0,0 +1,294 @@
+// Package ranking orders search results from several package managers by one
+// relevance score and groups the results that are the same project. Package
+// managers score their own results on scales that do not compare, so the
+// score blends signals every result can be judged by.
+package ranking
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/RichestHumanAlive/ppm_cli/pkg/manager"
+)
+
+// Weights sets how much each signal counts towards the relevance of a result.
+// Only the ratios between weights matter; a weight of 0 ignores the signal.
+type Weights struct {
+	Relevance  float64 // The package manager's own score, relative to its other results
+	Exact      float64 // The name is the query
+	Similarity float64 // How closely the name matches the query
+	Popularity float64 // Downloads, on a log scale
+	Recency    float64 // How recently the latest version was released
+	Available  float64 // The package manager is installed on this host
+}
+
+// DefaultWeights returns the weights used unless configured otherwise
+func DefaultWeights() Weights {
+	return Weights{
+		Relevance:  1,
+		Exact:      2,
+		Similarity: 2,
+		Popularity: 1,
+		Recency:    0.5,
+		Available:  0.5,
+	}
+}
+
+// WeightNames lists the signal names ParseWeights accepts
+var WeightNames = []string{"relevance", "exact", "similarity", "popularity", "recency", "available"}
+
+// field returns the weight of the named signal
+func (w *Weights) field(name string) *float64 {
+	switch name {
+	case "relevance":
+		return &w.Relevance
+	case "exact":
+		return &w.Exact
+	case "similarity":
+		return &w.Similarity
+	case "popularity":
+		return &w.Popularity
+	case "recency":
+		return &w.Recency
+	case "available":
+		return &w.Available
+	}
+	return nil
+}
+
+// String formats the weights the way ParseWeights reads them
+func (w Weights) String() string {
+	parts := make([]string, 0, len(WeightNames))
+	for _, name := range WeightNames {
+		parts = append(parts, name+"="+strconv.FormatFloat(*w.field(name), 'g', -1, 64))
+	}
+	return strings.Join(parts, ",")
+}
+
+// ParseWeights reads comma separated name=weight pairs such as
+// "exact=4,popularity=0.5". Signals the string does not mention keep their
+// weight in base.
+func ParseWeights(s string, base Weights) (Weights, error) {
+	w := base
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return base, fmt.Errorf("invalid weight %q (expected name=number)", pair)
+		}
+		field := w.field(strings.ToLower(strings.TrimSpace(name)))
+		if field == nil {
+			return base, fmt.Errorf("unknown signal %q (expected %s)", name, strings.Join(WeightNames, ", "))
+		}
+		weight, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || weight < 0 || math.IsInf(weight, 0) || math.IsNaN(weight) {
+			return base, fmt.Errorf("invalid weight %q for %s (expected a number of at least 0)", value, name)
+		}
+		*field = weight
+	}
+	return w, nil
+}
+
+const (
+	// popularDownloads is the monthly download count that earns full marks
+	// for popularity
+	popularDownloads = 10_000_000
+
+	// recencyHalfLife is how long it takes a release to lose half of its
+	// recency
+	recencyHalfLife = 365 * 24 * time.Hour
+)
+
+// Ranker scores and orders search results
+type Ranker struct {
+	weights   Weights
+	available func(provider string) bool
+	now       func() time.Time
+}
+
+// Option configures a Ranker
+type Option func(*Ranker)
+
+// WithWeights sets the weights of the signals
+func WithWeights(w Weights) Option {
+	return func(r *Ranker) {
+		r.weights = w
+	}
+}
+
+// WithAvailable sets how the ranker learns whether a package manager is
+// installed on this host. Without it, availability is not taken into account.
+func WithAvailable(available func(provider string) bool) Option {
+	return func(r *Ranker) {
+		r.available = available
+	}
+}
+
+// WithClock sets the clock recency is measured against
+func WithClock(now func() time.Time) Option {
+	return func(r *Ranker) {
+		r.now = now
+	}
+}
+
+// New returns a Ranker with the default weights
+func New(opts ...Option) *Ranker {
+	r := &Ranker{
+		weights: DefaultWeights(),
+		now:     time.Now,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Rank returns the results best first with Score replaced by their blended
+// relevance between 0 and 1, leaving pkgs untouched. Results that score the
+// same keep their order.
+func (r *Ranker) Rank(query string, pkgs []manager.Package) []manager.Package {
+	// The package managers' own scores only compare among their results
+	best := make(map[string]float64)
+	for _, pkg := range pkgs {
+		if pkg.Score > best[pkg.Provider] {
+			best[pkg.Provider] = pkg.Score
+		}
+	}
+
+	ranked := make([]manager.Package, len(pkgs))
+	for i, pkg := range pkgs {
+		relevance := 0.0
+		if best[pkg.Provider] > 0 {
+			relevance = pkg.Score / best[pkg.Provider]
+		}
+		pkg.Score = r.score(query, pkg, relevance)
+		ranked[i] = pkg
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].Score > ranked[j].Score
+	})
+	return ranked
+}
+
+// score blends the signals of a result. Signals its package manager does not
+// report, such as downloads, are left out rather than counted as zero, so that
+// results are not punished for what their registry does not publish.
+func (r *Ranker) score(query string, pkg manager.Package, relevance float64) float64 {
+	var sum, total float64
+	add := func(weight, signal float64) {
+		sum += weight * signal
+		total += weight
+	}
+
+	add(r.weights.Relevance, relevance)
+	if manager.SameName(pkg.Provider, pkg.Name, query) {
+		add(r.weights.Exact, 1)
+	} else {
+		add(r.weights.Exact, 0)
+	}
+	add(r.weights.Similarity, Similarity(query, pkg.Name))
+	if pkg.Downloads > 0 {
+		add(r.weights.Popularity, popularity(pkg.Downloads))
+	}
+	if released, err := time.Parse(manager.ReleaseDateFormat, pkg.Updated); err == nil {
+		add(r.weights.Recency, recency(r.now().Sub(released)))
+	}
+	if r.available != nil {
+		if r.available(pkg.Provider) {
+			add(r.weights.Available, 1)
+		} else {
+			add(r.weights.Available, 0)
+		}
+	}
+
+	if total == 0 {
+		return 0
+	}
+	return sum / total
+}
+
+// Similarity scores how closely a package name matches a query between 0 and
+// 1: the same name scores 1, prefixes beat other substrings, shorter names
+// beat longer ones, and names that contain no part of the query score by edit
+// distance. Scoped and bucket-qualified names ("@types/node", "extras/git")
+// also match by the part after the slash.
+func Similarity(query, name string) float64 {
+	query = normalize(query)
+	if query == "" {
+		return 0
+	}
+
+	score := similarity(query, normalize(name))
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		// The scope is not part of the name's own match, so cap it below
+		// an exact match of the whole name
+		if base := 0.95 * similarity(query, normalize(name[i+1:])); base > score {
+			score = base
+		}
+	}
+	return score
+}
+
+// similarity scores names by manager.MatchScore, falling back to edit
+// distance for names that do not contain the query
+func similarity(query, name string) float64 {
+	if score := manager.MatchScore(query, name); score > 0 {
+		return score
+	}
+	if name == "" {
+		return 0
+	}
+
+	longest := len(name)
+	if len(query) > longest {
+		longest = len(query)
+	}
+	return 0.4 * (1 - float64(levenshtein(query, name))/float64(longest))
+}
+
+// normalize lowercases a name and folds the separators pip treats alike
+func normalize(name string) string {
+	return strings.NewReplacer("_", "-", ".", "-").Replace(strings.ToLower(strings.TrimSpace(name)))
+}
+
+// levenshtein returns the edit distance between two strings in bytes
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// popularity maps a monthly download count onto 0-1 on a log scale
+func popularity(downloads int64) float64 {
+	return math.Min(1, math.Log10(float64(downloads)+1)/math.Log10(popularDownloads+1))
+}
+
+// recency maps the age of a release onto 0-1, halving every recencyHalfLife
+func recency(age time.Duration) float64 {
+	if age <= 0 {
+		return 1
+	}
+	return math.Pow(0.5, float64(age)/float64(recencyHalfLife))
+}
@@ -0,0 +1,185 @@
+package ranking
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RichestHumanAlive/ppm_cli/pkg/manager"
+)
+
+func names(pkgs []manager.Package) []string {
+	out := make([]string, 0, len(pkgs))
+	for _, pkg := range pkgs {
+		out = append(out, pkg.Provider+":"+pkg.Name)
+	}
+	return out
+}
+
+func TestParseWeights(t *testing.T) {
+	w, err := ParseWeights(" exact=4, Popularity=0.5 ,", DefaultWeights())
+	if err != nil {
+		t.Fatalf("ParseWeights() error = %v", err)
+	}
+	want := DefaultWeights()
+	want.Exact, want.Popularity = 4, 0.5
+	if w != want {
+		t.Errorf("ParseWeights() = %+v, want %+v", w, want)
+	}
+
+	for _, s := range []string{"exact", "fame=1", "exact=high", "exact=-1"} {
+		if _, err := ParseWeights(s, DefaultWeights()); err == nil {
+			t.Errorf("ParseWeights(%q) error = nil, want error", s)
+		}
+	}
+
+	if round, err := ParseWeights(want.String(), Weights{}); err != nil || round != want {
+		t.Errorf("ParseWeights(String()) = %+v, %v, want %+v", round, err, want)
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		query, name string
+		want        float64
+	}{
+		{"prettier", "prettier", 1},
+		{"Flask_Login", "flask-login", 1},
+		{"node", "@types/node", 0.95},
+		{"", "prettier", 0},
+	}
+	for _, tt := range tests {
+		if got := Similarity(tt.query, tt.name); got != tt.want {
+			t.Errorf("Similarity(%q, %q) = %v, want %v", tt.query, tt.name, got, tt.want)
+		}
+	}
+
+	// Prefixes beat substrings, which beat typos, which beat unrelated names
+	order := []string{"prettier", "prettier-eslint", "eslint-prettier", "pretier", "black"}
+	for i := 1; i < len(order); i++ {
+		if a, b := Similarity("prettier", order[i-1]), Similarity("prettier", order[i]); a <= b {
+			t.Errorf("Similarity(%s) = %v <= Similarity(%s) = %v", order[i-1], a, order[i], b)
+		}
+	}
+}
+
+func TestRankNormalizesProviderScores(t *testing.T) {
+	// pip scores its only result 0.8 and npm its best 0.3: both are the best
+	// their package manager has, so neither wins on score alone
+	pkgs := []manager.Package{
+		{Name: "black-formatter", Provider: "npm", Score: 0.3},
+		{Name: "black-formatter", Provider: "pip", Score: 0.8},
+		{Name: "black-formatter-cli", Provider: "npm", Score: 0.15},
+	}
+	ranked := New(WithWeights(Weights{Relevance: 1})).Rank("black", pkgs)
+	if ranked[0].Score != 1 || ranked[1].Score != 1 || ranked[2].Score != 0.5 {
+		t.Errorf("Rank() = %v", ranked)
+	}
+	if pkgs[0].Score != 0.3 {
+		t.Errorf("Rank() changed its input: %v", pkgs)
+	}
+}
+
+func TestRank(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	pkgs := []manager.Package{
+		{Name: "prettier-plugin-x", Provider: "npm", Score: 1, Downloads: 1000, Updated: "2019-01-01"},
+		{Name: "prettier", Provider: "npm", Score: 0.9, Downloads: 50_000_000, Updated: "2024-05-01"},
+		{Name: "prettier", Provider: "scoop", Score: 1},
+		{Name: "prettierd", Provider: "pip", Score: 0.7},
+	}
+	available := func(provider string) bool { return provider != "scoop" }
+	ranker := New(WithClock(func() time.Time { return now }), WithAvailable(available))
+
+	got := names(ranker.Rank("prettier", pkgs))
+	want := []string{"npm:prettier", "scoop:prettier", "pip:prettierd", "npm:prettier-plugin-x"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Rank() = %v, want %v", got, want)
+		}
+	}
+
+	// Weights change the order: with only popularity and recency counting,
+	// unknown signals leave scoop and pip at 0
+	ranker = New(WithClock(func() time.Time { return now }), WithWeights(Weights{Popularity: 1, Recency: 1}))
+	got = names(ranker.Rank("prettier", pkgs))
+	if got[0] != "npm:prettier" || got[1] != "npm:prettier-plugin-x" {
+		t.Errorf("Rank() with popularity and recency = %v", got)
+	}
+}
+
+func TestRecencyAndPopularity(t *testing.T) {
+	if got := recency(365 * 24 * time.Hour); got != 0.5 {
+		t.Errorf("recency(1 year) = %v, want 0.5", got)
+	}
+	if got := recency(-time.Hour); got != 1 {
+		t.Errorf("recency(future) = %v, want 1", got)
+	}
+	if got := popularity(popularDownloads * 10); got != 1 {
+		t.Errorf("popularity(lots) = %v, want 1", got)
+	}
+	if a, b := popularity(1000), popularity(100_000); a >= b {
+		t.Errorf("popularity(1000) = %v >= popularity(100000) = %v", a, b)
+	}
+}
+
+func TestProjectKey(t *testing.T) {
+	tests := []struct {
+		url, want string
+	}{
+		{"git+https://github.com/psf/black.git", "github.com/psf/black"},
+		{"https://github.com/psf/black#readme", "github.com/psf/black"},
+		{"https://GitHub.com/psf/black/tree/main/docs", "github.com/psf/black"},
+		{"git@github.com:psf/black.git", "github.com/psf/black"},
+		{"ssh://git@gitlab.com/group/project", "gitlab.com/group/project"},
+		{"https://www.prettier.io/", "prettier.io"},
+		{"https://github.com/psf", ""},
+		{"", ""},
+		{"not a url", ""},
+	}
+	for _, tt := range tests {
+		if got := ProjectKey(tt.url); got != tt.want {
+			t.Errorf("ProjectKey(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestGroupProjects(t *testing.T) {
+	pkgs := []manager.Package{
+		{Name: "black", Provider: "pip", Repository: "https://github.com/psf/black"},
+		{Name: "prettier", Provider: "npm", Homepage: "https://prettier.io", Repository: "git+https://github.com/prettier/prettier.git"},
+		{Name: "black", Provider: "npm"},
+		{Name: "prettier", Provider: "scoop", Homepage: "https://prettier.io/"},
+		{Name: "black", Provider: "scoop", Homepage: "https://github.com/psf/black"},
+		// Packages of one monorepo are not the same project
+		{Name: "@babel/core", Provider: "npm", Repository: "https://github.com/babel/babel"},
+		{Name: "@babel/cli", Provider: "npm", Repository: "https://github.com/babel/babel"},
+		{Name: "babel", Provider: "scoop", Homepage: "https://github.com/babel/babel"},
+	}
+
+	groups := GroupProjects(pkgs, []string{"scoop"})
+	var got [][]string
+	for _, g := range groups {
+		got = append(got, names(g.Packages))
+	}
+	want := [][]string{
+		{"scoop:black", "pip:black"},
+		{"scoop:prettier", "npm:prettier"},
+		{"npm:black"},
+		{"npm:@babel/core"},
+		{"npm:@babel/cli"},
+		{"scoop:babel"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("GroupProjects() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if strings.Join(got[i], " ") != strings.Join(want[i], " ") {
+			t.Errorf("GroupProjects()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+
+	if g := GroupProjects(pkgs, nil)[0]; g.Preferred().Provider != "pip" || len(g.Alternatives()) != 1 {
+		t.Errorf("GroupProjects() without priority = %v, want pip first", names(g.Packages))
+	}
+}